@@ -1,6 +1,7 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net/http"
 	"os"
@@ -10,7 +11,9 @@ import (
 	"cinema-reservation/internal/database"
 	"cinema-reservation/internal/handlers"
 	"cinema-reservation/internal/middleware"
+	"cinema-reservation/internal/models"
 	"cinema-reservation/internal/repositories"
+	scriptloader "cinema-reservation/internal/scripts"
 	"cinema-reservation/internal/services"
 	validators "cinema-reservation/internal/validator"
 
@@ -23,6 +26,9 @@ import (
 func main() {
 	// Load configuration
 	cfg := config.Load()
+	if cfg.JWTSecret == "" {
+		log.Fatal("JWT_SECRET must be set")
+	}
 
 	// Initialize databases
 	db, err := database.NewPostgres(cfg.DatabaseURL)
@@ -35,6 +41,16 @@ func main() {
 		log.Fatal("Failed to connect to Redis:", err)
 	}
 
+	// Load the Lua scripts into Redis so they can be run by SHA
+	scripts, err := scriptloader.NewRegistry(redis)
+	if err != nil {
+		log.Fatal("Failed to read Lua scripts:", err)
+	}
+	err = scripts.Load(context.Background())
+	if err != nil {
+		log.Fatal("Failed to load Lua scripts into Redis:", err)
+	}
+
 	// Register custom validators
 	if v, ok := binding.Validator.Engine().(*validator.Validate); ok {
 		validators.RegisterCustomValidators(v)
@@ -45,25 +61,56 @@ func main() {
 
 	// Initialize repositories
 	cinemaRepo := repositories.NewCinemaRepository(db)
+	movieRepo := repositories.NewMovieRepository(db)
+	showtimeRepo := repositories.NewShowtimeRepository(db)
 	reservationRepo := repositories.NewReservationRepository(db, redis)
+	seatReleaseRepo := repositories.NewSeatReleaseRepository(db)
+	seatPriceRepo := repositories.NewSeatPriceRepository(db)
+	userRepo := repositories.NewUserRepository(db)
+	apiKeyRepo := repositories.NewAPIKeyRepository(db)
 
 	// Initialize services
-	cinemaService := services.NewCinemaService(cinemaRepo, redis)
-	reservationService := services.NewReservationService(reservationRepo, cinemaRepo, redis)
-	appService := services.NewAppService(reservationRepo, redis)
+	seatEventHub := services.NewSeatEventHub(redis)
+	cinemaService := services.NewCinemaService(cinemaRepo, showtimeRepo, seatPriceRepo, reservationRepo, seatEventHub, redis, cfg.SeatCacheTTL)
+	movieService := services.NewMovieService(movieRepo)
+	showtimeService := services.NewShowtimeService(showtimeRepo, cinemaRepo, movieRepo, reservationRepo, redis)
+	seatReleaseService := services.NewSeatReleaseService(seatReleaseRepo, reservationRepo, redis, scripts)
+	reservationService := services.NewReservationService(reservationRepo, showtimeRepo, seatPriceRepo, seatReleaseService, redis, scripts, cfg.HoldTTL)
+	authService := services.NewAuthService(userRepo, cinemaRepo, cfg.JWTSecret, cfg.JWTTTL)
+	apiKeyService := services.NewAPIKeyService(apiKeyRepo, cinemaRepo, redis)
+	appService := services.NewAppService(reservationRepo, cinemaRepo, showtimeRepo, redis, scripts)
 
 	err = appService.SyncReservationsToRedis()
 	if err != nil {
 		log.Fatal("Failed to sync reservations to Redis:", err)
 	}
 
+	// Release expired seat holds in the background
+	go reservationService.StartHoldSweeper(context.Background(), cfg.HoldSweepInterval)
+
+	// Repair drift between Redis and the DB in the background
+	go appService.StartReconciler(context.Background(), cfg.ReconcileInterval)
+
+	// Retry seat releases that failed on Redis
+	go seatReleaseService.StartWorker(context.Background(), cfg.SeatReleaseInterval)
+
+	// Fan out seat changes published by every instance to live seat maps
+	go seatEventHub.Run(context.Background())
+
 	// Initialize handlers
 	cinemaHandler := handlers.NewCinemaHandler(cinemaService)
+	movieHandler := handlers.NewMovieHandler(movieService)
+	showtimeHandler := handlers.NewShowtimeHandler(showtimeService)
 	reservationHandler := handlers.NewReservationHandler(reservationService)
-	healthHandler := handlers.NewHealthHandler(db, redis)
+	holdHandler := handlers.NewHoldHandler(reservationService)
+	adminHandler := handlers.NewAdminHandler(appService, seatReleaseService)
+	healthHandler := handlers.NewHealthHandler(db, redis, scripts)
+	authHandler := handlers.NewAuthHandler(authService)
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+	auth := middleware.NewAuth(authService)
 
 	// Setup router
-	router := setupRouter(cinemaHandler, reservationHandler, healthHandler, redis)
+	router := setupRouter(cinemaHandler, movieHandler, showtimeHandler, reservationHandler, holdHandler, adminHandler, healthHandler, authHandler, apiKeyHandler, auth, apiKeyService, redis, scripts, cfg)
 
 	// Start server
 	port := os.Getenv("PORT")
@@ -77,9 +124,19 @@ func main() {
 
 func setupRouter(
 	cinemaHandler *handlers.CinemaHandler,
+	movieHandler *handlers.MovieHandler,
+	showtimeHandler *handlers.ShowtimeHandler,
 	reservationHandler *handlers.ReservationHandler,
+	holdHandler *handlers.HoldHandler,
+	adminHandler *handlers.AdminHandler,
 	healthHandler *handlers.HealthHandler,
+	authHandler *handlers.AuthHandler,
+	apiKeyHandler *handlers.APIKeyHandler,
+	auth *middleware.Auth,
+	apiKeyService services.APIKeyService,
 	redis *redis.Client,
+	scripts *scriptloader.Registry,
+	cfg *config.Config,
 ) *gin.Engine {
 	router := gin.New()
 
@@ -88,29 +145,95 @@ func setupRouter(
 	router.Use(middleware.Recovery())
 	router.Use(middleware.CORS())
 
-	// Rate limiting middleware (100 requests per minute per IP)
-	rateLimiter := middleware.NewRateLimiter(redis, 100, time.Minute)
-	router.Use(rateLimiter.Middleware())
-
 	// Health check (no rate limiting)
 	router.GET("/health", healthHandler.Check)
 
+	// Rate limiting per IP, with separate read and write limits per minute
+	// and stricter ones on booking. Requests with an X-API-Key are limited by
+	// the quota of their key instead.
+	rateLimiter := middleware.NewRateLimiter(redis, scripts, cfg.RateLimitRead, cfg.RateLimitWrite, time.Minute)
+	apiKeyAuth := middleware.NewAPIKeyAuth(apiKeyService, rateLimiter)
+	reserveLimit := rateLimiter.Route("reserve", cfg.RateLimitReserve, time.Minute)
+
+	// Replays the response of retried requests with an Idempotency-Key
+	idempotency := middleware.NewIdempotency(redis, scripts, cfg.IdempotencyTTL)
+
+	// Access policies, each runs after authenticated
+	authenticated := auth.Middleware()
+	adminOnly := auth.Require(models.UserRoleAdmin)
+	managerOrAdmin := auth.Require(models.UserRoleManager, models.UserRoleAdmin)
+	cinemaManager := auth.RequireCinemaManager()
+	// Identifies callers on public routes without requiring a token
+	optionalAuth := auth.Optional()
+
 	// API routes
-	v1 := router.Group("/api/v1")
+	v1 := router.Group("/api/v1", apiKeyAuth.Middleware(), rateLimiter.Middleware())
 	{
+		// Account routes
+		v1.POST("/auth/register", authHandler.Register)
+		v1.POST("/auth/login", authHandler.Login)
+		v1.GET("/me/reservations", authenticated, reservationHandler.ListMyReservations)
+
 		// Cinema routes
 		cinemas := v1.Group("/cinemas")
 		{
-			cinemas.POST("", cinemaHandler.CreateLayout)
-			cinemas.GET("/:slug/seats", cinemaHandler.GetAvailableSeats)
+			cinemas.POST("", authenticated, adminOnly, cinemaHandler.CreateLayout)
+			cinemas.GET("/:slug/seats", optionalAuth, cinemaHandler.GetAvailableSeats)
 			cinemas.POST("/:slug/seats/check-availability", cinemaHandler.CheckAvailableSeats)
+			cinemas.GET("/:slug/seats/recommend", cinemaHandler.RecommendSeats)
+			cinemas.GET("/:slug/seatmap", cinemaHandler.GetSeatMap)
+			cinemas.GET("/:slug/seats/stream", cinemaHandler.StreamSeatMap)
+			cinemas.GET("/:slug/seats/ws", cinemaHandler.WatchSeatMapWS)
+			cinemas.GET("/:slug/prices", cinemaHandler.GetSeatPrices)
+			cinemas.PUT("/:slug/prices", authenticated, cinemaManager, cinemaHandler.SetSeatPrices)
+
+			// Showtime routes
+			cinemas.POST("/:slug/showtimes", authenticated, cinemaManager, showtimeHandler.CreateShowtime)
+			cinemas.GET("/:slug/showtimes", showtimeHandler.ListShowtimes)
+			cinemas.GET("/:slug/showtimes/:id", showtimeHandler.GetShowtime)
+			cinemas.PUT("/:slug/showtimes/:id", authenticated, cinemaManager, showtimeHandler.UpdateShowtime)
+			cinemas.DELETE("/:slug/showtimes/:id", authenticated, cinemaManager, showtimeHandler.DeleteShowtime)
+		}
+
+		// Movie routes
+		movies := v1.Group("/movies")
+		{
+			movies.POST("", authenticated, managerOrAdmin, movieHandler.CreateMovie)
+			movies.GET("", movieHandler.ListMovies)
 		}
 
 		// Reservation routes
-		reservations := v1.Group("/reservations")
+		reservations := v1.Group("/reservations", authenticated)
+		{
+			reservations.POST("", reserveLimit, idempotency.Middleware(), reservationHandler.ReserveSeats)
+			reservations.DELETE("", idempotency.Middleware(), reservationHandler.CancelSeats)
+			reservations.GET("/by-code/:code", reservationHandler.GetReservationByCode)
+			reservations.GET("/:id", reservationHandler.GetReservation)
+			reservations.DELETE("/:id", reservationHandler.CancelReservation)
+			reservations.DELETE("/:id/seats", reservationHandler.CancelReservationSeats)
+		}
+
+		// Hold routes
+		holds := v1.Group("/holds", authenticated)
+		{
+			holds.POST("", reserveLimit, holdHandler.HoldSeats)
+			holds.POST("/:id/confirm", holdHandler.ConfirmHold)
+			holds.DELETE("/:id", holdHandler.ReleaseHold)
+		}
+
+		// Admin routes
+		admin := v1.Group("/admin", authenticated)
 		{
-			reservations.POST("", reservationHandler.ReserveSeats)
-			reservations.DELETE("", reservationHandler.CancelSeats)
+			admin.POST("/resync", adminOnly, adminHandler.ResyncAll)
+			admin.POST("/cinemas/:slug/resync", cinemaManager, adminHandler.ResyncCinema)
+			admin.GET("/seat-releases", adminOnly, adminHandler.ListSeatReleases)
+			admin.POST("/seat-releases/:id/retry", adminOnly, adminHandler.RetrySeatRelease)
+			admin.DELETE("/seat-releases/:id", adminOnly, adminHandler.DiscardSeatRelease)
+			admin.PUT("/users/:id/role", adminOnly, authHandler.SetUserRole)
+			admin.POST("/api-keys", adminOnly, apiKeyHandler.CreateKey)
+			admin.GET("/api-keys", adminOnly, apiKeyHandler.ListKeys)
+			admin.DELETE("/api-keys/:id", adminOnly, apiKeyHandler.RevokeKey)
+			admin.GET("/api-keys/:id/usage", adminOnly, apiKeyHandler.GetUsage)
 		}
 	}
 
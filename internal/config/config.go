@@ -3,14 +3,28 @@ package config
 import (
 	"log"
 	"os"
+	"strconv"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	DatabaseURL string
-	RedisURL    string
-	Port        string
+	DatabaseURL         string
+	RedisURL            string
+	Port                string
+	HoldTTL             time.Duration
+	HoldSweepInterval   time.Duration
+	ReconcileInterval   time.Duration
+	SeatReleaseInterval time.Duration
+	SeatCacheTTL        time.Duration
+	IdempotencyTTL      time.Duration
+	JWTSecret           string
+	JWTTTL              time.Duration
+	// Requests per minute of a client IP, see middleware.RateLimiter
+	RateLimitRead    int
+	RateLimitWrite   int
+	RateLimitReserve int
 }
 
 func Load() *Config {
@@ -21,9 +35,20 @@ func Load() *Config {
 	}
 
 	return &Config{
-		DatabaseURL: getEnv("DATABASE_URL", ""),
-		RedisURL:    getEnv("REDIS_URL", ""),
-		Port:        getEnv("PORT", "8080"),
+		DatabaseURL:         getEnv("DATABASE_URL", ""),
+		RedisURL:            getEnv("REDIS_URL", ""),
+		Port:                getEnv("PORT", "8080"),
+		HoldTTL:             getEnvDuration("HOLD_TTL", 5*time.Minute),
+		HoldSweepInterval:   getEnvDuration("HOLD_SWEEP_INTERVAL", 10*time.Second),
+		ReconcileInterval:   getEnvDuration("RECONCILE_INTERVAL", time.Minute),
+		SeatReleaseInterval: getEnvDuration("SEAT_RELEASE_INTERVAL", 5*time.Second),
+		SeatCacheTTL:        getEnvDuration("SEAT_CACHE_TTL", 5*time.Second),
+		IdempotencyTTL:      getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour),
+		JWTSecret:           getEnv("JWT_SECRET", ""),
+		JWTTTL:              getEnvDuration("JWT_TTL", 24*time.Hour),
+		RateLimitRead:       getEnvInt("RATE_LIMIT_READ", 300),
+		RateLimitWrite:      getEnvInt("RATE_LIMIT_WRITE", 100),
+		RateLimitReserve:    getEnvInt("RATE_LIMIT_RESERVE", 20),
 	}
 }
 
@@ -33,3 +58,31 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil || duration <= 0 {
+		log.Printf("Invalid duration for %s: %q, using %v", key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+func getEnvInt(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	number, err := strconv.Atoi(value)
+	if err != nil || number <= 0 {
+		log.Printf("Invalid number for %s: %q, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return number
+}
@@ -0,0 +1,122 @@
+package database
+
+import (
+	"fmt"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/utils"
+
+	"gorm.io/gorm"
+)
+
+// legacyMovieSlug names the placeholder movie of the showtimes that take in
+// reservations made before showtimes existed.
+const legacyMovieSlug = "legacy-reservations"
+
+// backfillShowtimes gives reservations made before showtimes existed a
+// showtime, one per cinema, so AutoMigrate can add the NOT NULL showtime_id
+// columns to tables that already have rows. Seats were unique per cinema
+// back then, so they stay unique per showtime.
+func backfillShowtimes(db *gorm.DB) error {
+	migrator := db.Migrator()
+	if !migrator.HasTable(&models.Reservation{}) || migrator.HasColumn(&models.Reservation{}, "ShowtimeID") {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		err := tx.AutoMigrate(&models.Movie{}, &models.Showtime{})
+		if err != nil {
+			return err
+		}
+		err = tx.Exec("ALTER TABLE reservations ADD COLUMN showtime_id bigint").Error
+		if err != nil {
+			return err
+		}
+		hasSeats := tx.Migrator().HasTable(&models.ReservedSeat{})
+		if hasSeats {
+			err = tx.Exec("ALTER TABLE reserved_seats ADD COLUMN showtime_id bigint").Error
+			if err != nil {
+				return err
+			}
+		}
+
+		var cinemas []struct {
+			CinemaID   uint
+			ReservedAt time.Time
+		}
+		err = tx.Table("reservations").
+			Select("cinema_id, MIN(reserved_at) AS reserved_at").
+			Group("cinema_id").
+			Scan(&cinemas).Error
+		if err != nil || len(cinemas) == 0 {
+			return err
+		}
+
+		movie := models.Movie{Title: "Legacy reservations", Slug: legacyMovieSlug, DurationMinutes: 1}
+		err = tx.Where(models.Movie{Slug: legacyMovieSlug}).FirstOrCreate(&movie).Error
+		if err != nil {
+			return err
+		}
+
+		for _, cinema := range cinemas {
+			showtime := models.Showtime{
+				CinemaID: cinema.CinemaID,
+				MovieID:  movie.ID,
+				StartsAt: cinema.ReservedAt,
+				EndsAt:   cinema.ReservedAt,
+			}
+			err = tx.Create(&showtime).Error
+			if err != nil {
+				return err
+			}
+			err = tx.Exec("UPDATE reservations SET showtime_id = ? WHERE cinema_id = ?", showtime.ID, cinema.CinemaID).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		if hasSeats {
+			return tx.Exec(`UPDATE reserved_seats SET showtime_id = reservations.showtime_id
+				FROM reservations WHERE reservations.id = reserved_seats.reservation_id`).Error
+		}
+		return nil
+	})
+}
+
+// backfillBookingCodes gives reservations made before booking codes existed
+// a code, which AutoMigrate left empty when it added the column.
+func backfillBookingCodes(db *gorm.DB) error {
+	var used []string
+	err := db.Unscoped().Model(&models.Reservation{}).Where("code <> ''").Pluck("code", &used).Error
+	if err != nil {
+		return err
+	}
+	taken := make(map[string]bool, len(used))
+	for _, code := range used {
+		taken[code] = true
+	}
+
+	var ids []uint
+	err = db.Unscoped().Model(&models.Reservation{}).Where("code IS NULL OR code = ''").Pluck("id", &ids).Error
+	if err != nil {
+		return err
+	}
+
+	for _, id := range ids {
+		code, err := utils.GenerateBookingCode()
+		for err == nil && taken[code] {
+			code, err = utils.GenerateBookingCode()
+		}
+		if err != nil {
+			return fmt.Errorf("failed to generate booking code: %w", err)
+		}
+		taken[code] = true
+
+		err = db.Exec("UPDATE reservations SET code = ? WHERE id = ?", code, id).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
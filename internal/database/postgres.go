@@ -2,6 +2,7 @@ package database
 
 import (
 	"cinema-reservation/internal/models"
+	"fmt"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -29,15 +30,43 @@ func NewPostgres(databaseURL string) (*gorm.DB, error) {
 	sqlDB.SetConnMaxLifetime(5 * time.Minute) // Maximum connection lifetime
 	sqlDB.SetConnMaxIdleTime(5 * time.Minute) // Maximum idle time
 
+	// Reservations from before showtimes need one before showtime_id can
+	// become NOT NULL
+	err = backfillShowtimes(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to backfill showtimes: %w", err)
+	}
+
 	// Auto migrate
 	err = db.AutoMigrate(
 		&models.Cinema{},
+		&models.Movie{},
+		&models.Showtime{},
 		&models.Reservation{},
 		&models.ReservedSeat{},
+		&models.SeatRelease{},
+		&models.SeatPrice{},
+		&models.User{},
+		&models.CinemaManager{},
+		&models.APIKey{},
+		&models.APIKeyCinema{},
 	)
 	if err != nil {
 		return nil, err
 	}
 
+	// Seats used to be unique per cinema; they are unique per showtime now
+	if db.Migrator().HasIndex(&models.ReservedSeat{}, "idx_cinema_seat") {
+		err = db.Migrator().DropIndex(&models.ReservedSeat{}, "idx_cinema_seat")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err = backfillBookingCodes(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to backfill booking codes: %w", err)
+	}
+
 	return db, nil
 }
@@ -0,0 +1,81 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cinema-reservation/internal/services"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminHandler struct {
+	appService         services.AppService
+	seatReleaseService services.SeatReleaseService
+}
+
+func NewAdminHandler(appService services.AppService, seatReleaseService services.SeatReleaseService) *AdminHandler {
+	return &AdminHandler{appService: appService, seatReleaseService: seatReleaseService}
+}
+
+func (h *AdminHandler) ResyncAll(c *gin.Context) {
+	report, err := h.appService.ResyncAll(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Seats resynced successfully", report)
+}
+
+func (h *AdminHandler) ResyncCinema(c *gin.Context) {
+	report, err := h.appService.ResyncCinema(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Cinema seats resynced successfully", report)
+}
+
+func (h *AdminHandler) ListSeatReleases(c *gin.Context) {
+	releases, err := h.seatReleaseService.ListReleases(c.Request.Context(), c.Query("status"))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Seat releases retrieved successfully", releases)
+}
+
+func (h *AdminHandler) RetrySeatRelease(c *gin.Context) {
+	releaseID, ok := parseID(c.Param("id"))
+	if !ok {
+		utils.ErrorResponse(c, utils.ErrInvalidSeatReleaseID)
+		return
+	}
+
+	release, err := h.seatReleaseService.RetryRelease(c.Request.Context(), releaseID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Seat release queued for retry", release)
+}
+
+func (h *AdminHandler) DiscardSeatRelease(c *gin.Context) {
+	releaseID, ok := parseID(c.Param("id"))
+	if !ok {
+		utils.ErrorResponse(c, utils.ErrInvalidSeatReleaseID)
+		return
+	}
+
+	err := h.seatReleaseService.DiscardRelease(c.Request.Context(), releaseID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Seat release discarded successfully", nil)
+}
@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/services"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// defaultUsageDays is the usage range shown without from and to.
+const defaultUsageDays = 30
+
+type APIKeyHandler struct {
+	apiKeyService services.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService services.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+func (h *APIKeyHandler) CreateKey(c *gin.Context) {
+	var req models.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	key, err := h.apiKeyService.CreateKey(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "API key created successfully, store the key now as it is not shown again", key)
+}
+
+func (h *APIKeyHandler) ListKeys(c *gin.Context) {
+	keys, err := h.apiKeyService.ListKeys(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API keys retrieved successfully", keys)
+}
+
+func (h *APIKeyHandler) RevokeKey(c *gin.Context) {
+	keyID, ok := parseID(c.Param("id"))
+	if !ok {
+		utils.ErrorResponse(c, utils.ErrInvalidAPIKeyID)
+		return
+	}
+
+	err := h.apiKeyService.RevokeKey(c.Request.Context(), keyID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key revoked successfully", nil)
+}
+
+// GetUsage returns the daily requests of a key between the from and to
+// query dates (YYYY-MM-DD), the last 30 days by default.
+func (h *APIKeyHandler) GetUsage(c *gin.Context) {
+	keyID, ok := parseID(c.Param("id"))
+	if !ok {
+		utils.ErrorResponse(c, utils.ErrInvalidAPIKeyID)
+		return
+	}
+
+	to := time.Now().UTC()
+	if value := c.Query("to"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidUsageRange)
+			return
+		}
+		to = parsed
+	}
+	from := to.AddDate(0, 0, 1-defaultUsageDays)
+	if value := c.Query("from"); value != "" {
+		parsed, err := time.Parse(time.DateOnly, value)
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidUsageRange)
+			return
+		}
+		from = parsed
+	}
+
+	usage, err := h.apiKeyService.GetUsage(c.Request.Context(), keyID, from, to)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "API key usage retrieved successfully", usage)
+}
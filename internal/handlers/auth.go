@@ -0,0 +1,73 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/services"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthHandler struct {
+	authService services.AuthService
+}
+
+func NewAuthHandler(authService services.AuthService) *AuthHandler {
+	return &AuthHandler{authService: authService}
+}
+
+func (h *AuthHandler) Register(c *gin.Context) {
+	var req models.RegisterRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	token, err := h.authService.Register(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Account created successfully", token)
+}
+
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req models.LoginRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	token, err := h.authService.Login(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Logged in successfully", token)
+}
+
+func (h *AuthHandler) SetUserRole(c *gin.Context) {
+	userID, ok := parseID(c.Param("id"))
+	if !ok {
+		utils.ErrorResponse(c, utils.ErrInvalidUserID)
+		return
+	}
+
+	var req models.SetUserRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	user, err := h.authService.SetUserRole(c.Request.Context(), userID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "User role updated successfully", user)
+}
@@ -4,6 +4,7 @@ import (
 	"net/http"
 	"strconv"
 
+	"cinema-reservation/internal/middleware"
 	"cinema-reservation/internal/models"
 	"cinema-reservation/internal/services"
 	"cinema-reservation/internal/utils"
@@ -35,32 +36,84 @@ func (h *CinemaHandler) CreateLayout(c *gin.Context) {
 	utils.SuccessResponse(c, http.StatusCreated, "Cinema created successfully", cinema)
 }
 
+// maxAvailableSeatsLimit caps a page of available seats. Without a limit
+// every block is returned.
+const maxAvailableSeatsLimit = 1000
+
 func (h *CinemaHandler) GetAvailableSeats(c *gin.Context) {
 	slug := c.Param("slug")
+	showtimeID, err := showtimeIDQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
 	nStr := c.Query("number_of_seats")
 	numberOfSeats, err := strconv.Atoi(nStr)
 	if err != nil || numberOfSeats <= 0 {
 		numberOfSeats = 1
 	}
 
-	available, err := h.cinemaService.GetAvailableSeats(c.Request.Context(), slug, numberOfSeats)
+	query := &models.AvailableSeatsQuery{
+		GroupSize:     numberOfSeats,
+		Shape:         models.SeatBlockShape(c.DefaultQuery("shape", string(models.BlockShapeRow))),
+		Accessibility: models.AccessibilityNeed(c.Query("accessibility")),
+		PartyCode:     c.Query("party_code"),
+		Format:        models.SeatBlockFormat(c.DefaultQuery("format", string(models.BlockFormatSeats))),
+		Cursor:        c.Query("cursor"),
+	}
+	if rowStr, ok := c.GetQuery("row"); ok {
+		row, err := strconv.Atoi(rowStr)
+		if err != nil || row < 0 {
+			utils.ErrorResponse(c, utils.ErrInvalidRow)
+			return
+		}
+		query.Row = &row
+	}
+	if limit, err := strconv.Atoi(c.Query("limit")); err == nil && limit > 0 {
+		query.Limit = min(limit, maxAvailableSeatsLimit)
+	}
+	if !query.Format.Valid() {
+		utils.ErrorResponse(c, utils.ErrInvalidBlockFormat)
+		return
+	}
+	if !query.Shape.Valid() {
+		utils.ErrorResponse(c, utils.ErrInvalidBlockShape)
+		return
+	}
+	if !query.Accessibility.Valid() {
+		utils.ErrorResponse(c, utils.ErrInvalidAccessibility)
+		return
+	}
+
+	available, err := h.cinemaService.GetAvailableSeats(c.Request.Context(), middleware.CurrentCaller(c), slug, showtimeID, query)
 	if err != nil {
 		utils.ErrorResponse(c, err)
 		return
 	}
 
-	utils.SuccessResponse(c, http.StatusOK, "Available seats retrieved successfully", available)
+	var data interface{} = available.Blocks
+	if query.Format == models.BlockFormatRuns {
+		data = available.Runs
+	}
+	utils.PageResponse(c, http.StatusOK, "Available seats retrieved successfully", data, available.NextCursor)
 }
 
 func (h *CinemaHandler) CheckAvailableSeats(c *gin.Context) {
 	slug := c.Param("slug")
+	showtimeID, err := showtimeIDQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
 	var req models.CheckSeatsRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		utils.ErrorResponse(c, err)
 		return
 	}
 
-	available, err := h.cinemaService.CheckAvailableSeats(c.Request.Context(), slug, &req)
+	available, err := h.cinemaService.CheckAvailableSeats(c.Request.Context(), slug, showtimeID, &req)
 	if err != nil {
 		utils.ErrorResponse(c, err)
 		return
@@ -68,3 +121,81 @@ func (h *CinemaHandler) CheckAvailableSeats(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Check seats successfully", available)
 }
+
+const (
+	defaultRecommendLimit = 5
+	maxRecommendLimit     = 20
+)
+
+func (h *CinemaHandler) RecommendSeats(c *gin.Context) {
+	slug := c.Param("slug")
+	showtimeID, err := showtimeIDQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	numberOfSeats, err := strconv.Atoi(c.Query("number_of_seats"))
+	if err != nil || numberOfSeats <= 0 {
+		numberOfSeats = 1
+	}
+
+	limit, err := strconv.Atoi(c.Query("limit"))
+	if err != nil || limit <= 0 {
+		limit = defaultRecommendLimit
+	}
+	if limit > maxRecommendLimit {
+		limit = maxRecommendLimit
+	}
+
+	recommendations, err := h.cinemaService.RecommendSeats(c.Request.Context(), slug, showtimeID, numberOfSeats, limit)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Recommended seats retrieved successfully", recommendations)
+}
+
+func (h *CinemaHandler) GetSeatMap(c *gin.Context) {
+	slug := c.Param("slug")
+	showtimeID, err := showtimeIDQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	seatMap, err := h.cinemaService.GetSeatMap(c.Request.Context(), slug, showtimeID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Seat map retrieved successfully", seatMap)
+}
+
+func (h *CinemaHandler) GetSeatPrices(c *gin.Context) {
+	prices, err := h.cinemaService.GetSeatPrices(c.Request.Context(), c.Param("slug"))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Seat prices retrieved successfully", prices)
+}
+
+func (h *CinemaHandler) SetSeatPrices(c *gin.Context) {
+	var req models.SetSeatPricesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	prices, err := h.cinemaService.SetSeatPrices(c.Request.Context(), c.Param("slug"), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Seat prices updated successfully", prices)
+}
@@ -3,8 +3,10 @@ package handlers
 import (
 	"context"
 	"net/http"
+	"strings"
 	"time"
 
+	scriptloader "cinema-reservation/internal/scripts"
 	"cinema-reservation/internal/utils"
 
 	"github.com/gin-gonic/gin"
@@ -13,20 +15,23 @@ import (
 )
 
 type HealthHandler struct {
-	db    *gorm.DB
-	redis *redis.Client
+	db      *gorm.DB
+	redis   *redis.Client
+	scripts *scriptloader.Registry
 }
 
 type HealthStatus struct {
-	Status   string            `json:"status"`
-	Services map[string]string `json:"services"`
-	Time     time.Time         `json:"time"`
+	Status   string                      `json:"status"`
+	Services map[string]string           `json:"services"`
+	Scripts  []scriptloader.ScriptStatus `json:"scripts,omitempty"`
+	Time     time.Time                   `json:"time"`
 }
 
-func NewHealthHandler(db *gorm.DB, redis *redis.Client) *HealthHandler {
+func NewHealthHandler(db *gorm.DB, redis *redis.Client, scripts *scriptloader.Registry) *HealthHandler {
 	return &HealthHandler{
-		db:    db,
-		redis: redis,
+		db:      db,
+		redis:   redis,
+		scripts: scripts,
 	}
 }
 
@@ -64,6 +69,27 @@ func (h *HealthHandler) Check(c *gin.Context) {
 		health.Services["redis"] = "healthy"
 	}
 
+	// Check the Lua scripts. Missing ones (e.g. after a Redis restart) are
+	// loaded again on their next run, so they do not make the service
+	// unhealthy.
+	health.Scripts, err = h.scripts.Status(ctx)
+	if err != nil {
+		health.Status = "unhealthy"
+		health.Services["scripts"] = "error: " + err.Error()
+	} else {
+		var missing []string
+		for _, script := range health.Scripts {
+			if !script.Loaded {
+				missing = append(missing, script.Name)
+			}
+		}
+		if len(missing) > 0 {
+			health.Services["scripts"] = "missing: " + strings.Join(missing, ", ")
+		} else {
+			health.Services["scripts"] = "healthy"
+		}
+	}
+
 	statusCode := http.StatusOK
 	if health.Status == "unhealthy" {
 		statusCode = http.StatusServiceUnavailable
@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/services"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type HoldHandler struct {
+	reservationService services.ReservationService
+}
+
+func NewHoldHandler(reservationService services.ReservationService) *HoldHandler {
+	return &HoldHandler{reservationService: reservationService}
+}
+
+func (h *HoldHandler) HoldSeats(c *gin.Context) {
+	caller, ok := currentCaller(c)
+	if !ok {
+		return
+	}
+
+	var req models.HoldRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	hold, err := h.reservationService.HoldSeats(c.Request.Context(), caller, &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Seats held successfully", hold)
+}
+
+func (h *HoldHandler) ConfirmHold(c *gin.Context) {
+	caller, ok := currentCaller(c)
+	if !ok {
+		return
+	}
+
+	var req models.ConfirmHoldRequest
+	// The body is optional, it only carries the reservation note
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.ErrorResponse(c, err)
+			return
+		}
+	}
+
+	reservation, err := h.reservationService.ConfirmHold(c.Request.Context(), caller, c.Param("id"), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Hold confirmed successfully", reservation)
+}
+
+func (h *HoldHandler) ReleaseHold(c *gin.Context) {
+	caller, ok := currentCaller(c)
+	if !ok {
+		return
+	}
+
+	err := h.reservationService.ReleaseHold(c.Request.Context(), caller, c.Param("id"))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Hold released successfully", nil)
+}
@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/services"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type MovieHandler struct {
+	movieService services.MovieService
+}
+
+func NewMovieHandler(movieService services.MovieService) *MovieHandler {
+	return &MovieHandler{movieService: movieService}
+}
+
+func (h *MovieHandler) CreateMovie(c *gin.Context) {
+	var req models.CreateMovieRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	movie, err := h.movieService.CreateMovie(c.Request.Context(), &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Movie created successfully", movie)
+}
+
+func (h *MovieHandler) ListMovies(c *gin.Context) {
+	movies, err := h.movieService.ListMovies(c.Request.Context())
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Movies retrieved successfully", movies)
+}
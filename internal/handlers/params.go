@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"strconv"
+
+	"cinema-reservation/internal/middleware"
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseID parses a positive numeric identifier from a path or query value.
+func parseID(value string) (uint, bool) {
+	id, err := strconv.ParseUint(value, 10, 64)
+	if err != nil || id == 0 {
+		return 0, false
+	}
+	return uint(id), true
+}
+
+// showtimeIDQuery reads the required showtime_id query parameter used by the
+// seat endpoints.
+func showtimeIDQuery(c *gin.Context) (uint, error) {
+	id, ok := parseID(c.Query("showtime_id"))
+	if !ok {
+		return 0, utils.ErrInvalidShowtimeID
+	}
+	return id, nil
+}
+
+// currentCaller returns the authenticated caller, answering with an error
+// when the route is not behind the auth middleware.
+func currentCaller(c *gin.Context) (*models.Caller, bool) {
+	caller := middleware.CurrentCaller(c)
+	if caller == nil {
+		utils.ErrorResponse(c, utils.ErrUnauthorized)
+		return nil, false
+	}
+	return caller, true
+}
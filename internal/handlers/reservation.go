@@ -19,6 +19,11 @@ func NewReservationHandler(reservationService services.ReservationService) *Rese
 }
 
 func (h *ReservationHandler) ReserveSeats(c *gin.Context) {
+	caller, ok := currentCaller(c)
+	if !ok {
+		return
+	}
+
 	var req models.ReservationRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -26,7 +31,7 @@ func (h *ReservationHandler) ReserveSeats(c *gin.Context) {
 		return
 	}
 
-	reservation, err := h.reservationService.ReserveSeats(c.Request.Context(), &req)
+	reservation, err := h.reservationService.ReserveSeats(c.Request.Context(), caller, &req)
 	if err != nil {
 		utils.ErrorResponse(c, err)
 		return
@@ -36,6 +41,11 @@ func (h *ReservationHandler) ReserveSeats(c *gin.Context) {
 }
 
 func (h *ReservationHandler) CancelSeats(c *gin.Context) {
+	caller, ok := currentCaller(c)
+	if !ok {
+		return
+	}
+
 	var req models.CancelRequest
 
 	if err := c.ShouldBindJSON(&req); err != nil {
@@ -43,7 +53,7 @@ func (h *ReservationHandler) CancelSeats(c *gin.Context) {
 		return
 	}
 
-	err := h.reservationService.CancelSeats(c.Request.Context(), &req)
+	err := h.reservationService.CancelSeats(c.Request.Context(), caller, &req)
 	if err != nil {
 		utils.ErrorResponse(c, err)
 		return
@@ -51,3 +61,102 @@ func (h *ReservationHandler) CancelSeats(c *gin.Context) {
 
 	utils.SuccessResponse(c, http.StatusOK, "Seats canceled successfully", nil)
 }
+
+func (h *ReservationHandler) GetReservation(c *gin.Context) {
+	caller, ok := currentCaller(c)
+	if !ok {
+		return
+	}
+
+	reservationID, valid := parseID(c.Param("id"))
+	if !valid {
+		utils.ErrorResponse(c, utils.ErrInvalidReservationID)
+		return
+	}
+
+	reservation, err := h.reservationService.GetReservation(c.Request.Context(), caller, reservationID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reservation retrieved successfully", reservation)
+}
+
+func (h *ReservationHandler) GetReservationByCode(c *gin.Context) {
+	caller, ok := currentCaller(c)
+	if !ok {
+		return
+	}
+
+	reservation, err := h.reservationService.GetReservationByCode(c.Request.Context(), caller, c.Param("code"))
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reservation retrieved successfully", reservation)
+}
+
+func (h *ReservationHandler) CancelReservation(c *gin.Context) {
+	caller, ok := currentCaller(c)
+	if !ok {
+		return
+	}
+
+	reservationID, valid := parseID(c.Param("id"))
+	if !valid {
+		utils.ErrorResponse(c, utils.ErrInvalidReservationID)
+		return
+	}
+
+	err := h.reservationService.CancelReservation(c.Request.Context(), caller, reservationID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reservation canceled successfully", nil)
+}
+
+func (h *ReservationHandler) CancelReservationSeats(c *gin.Context) {
+	caller, ok := currentCaller(c)
+	if !ok {
+		return
+	}
+
+	reservationID, valid := parseID(c.Param("id"))
+	if !valid {
+		utils.ErrorResponse(c, utils.ErrInvalidReservationID)
+		return
+	}
+
+	var req models.CancelReservationSeatsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	err := h.reservationService.CancelReservationSeats(c.Request.Context(), caller, reservationID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Seats canceled successfully", nil)
+}
+
+func (h *ReservationHandler) ListMyReservations(c *gin.Context) {
+	caller, ok := currentCaller(c)
+	if !ok {
+		return
+	}
+
+	reservations, err := h.reservationService.ListUserReservations(c.Request.Context(), caller)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Reservations retrieved successfully", reservations)
+}
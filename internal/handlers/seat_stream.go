@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	seatStreamHeartbeat = 25 * time.Second
+	seatStreamWriteWait = 10 * time.Second
+)
+
+var seatMapUpgrader = websocket.Upgrader{
+	// The API is public and already allows every origin through CORS
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// seatStreamMessage is a single message pushed to WebSocket clients.
+type seatStreamMessage struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// StreamSeatMap pushes the seat map as Server-Sent Events: a "snapshot"
+// event first, then a "delta" event for every change.
+func (h *CinemaHandler) StreamSeatMap(c *gin.Context) {
+	slug := c.Param("slug")
+	showtimeID, err := showtimeIDQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	seatMap, deltas, err := h.cinemaService.WatchSeatMap(c.Request.Context(), slug, showtimeID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+
+	heartbeat := time.NewTicker(seatStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	c.SSEvent("snapshot", seatMap)
+	c.Writer.Flush()
+
+	c.Stream(func(w io.Writer) bool {
+		select {
+		case delta, ok := <-deltas:
+			if !ok {
+				return false
+			}
+			c.SSEvent("delta", delta)
+		case <-heartbeat.C:
+			c.SSEvent("heartbeat", time.Now().Unix())
+		}
+		return true
+	})
+}
+
+// WatchSeatMapWS pushes the same snapshot and delta messages over a
+// WebSocket connection.
+func (h *CinemaHandler) WatchSeatMapWS(c *gin.Context) {
+	slug := c.Param("slug")
+	showtimeID, err := showtimeIDQuery(c)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	seatMap, deltas, err := h.cinemaService.WatchSeatMap(ctx, slug, showtimeID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	conn, err := seatMapUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// The upgrader has already written an error response
+		logrus.WithError(err).Warn("failed to upgrade seat map connection")
+		return
+	}
+	defer conn.Close()
+
+	// Clients only listen, so reading just detects a closed connection
+	go func() {
+		defer cancel()
+		for {
+			if _, _, err := conn.ReadMessage(); err != nil {
+				return
+			}
+		}
+	}()
+
+	if err := writeSeatStreamMessage(conn, "snapshot", seatMap); err != nil {
+		return
+	}
+
+	heartbeat := time.NewTicker(seatStreamHeartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case delta, ok := <-deltas:
+			if !ok {
+				return
+			}
+			if err := writeSeatStreamMessage(conn, "delta", delta); err != nil {
+				return
+			}
+		case <-heartbeat.C:
+			conn.SetWriteDeadline(time.Now().Add(seatStreamWriteWait))
+			if err := conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+func writeSeatStreamMessage(conn *websocket.Conn, messageType string, data interface{}) error {
+	conn.SetWriteDeadline(time.Now().Add(seatStreamWriteWait))
+	return conn.WriteJSON(seatStreamMessage{Type: messageType, Data: data})
+}
@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/services"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ShowtimeHandler struct {
+	showtimeService services.ShowtimeService
+}
+
+func NewShowtimeHandler(showtimeService services.ShowtimeService) *ShowtimeHandler {
+	return &ShowtimeHandler{showtimeService: showtimeService}
+}
+
+func (h *ShowtimeHandler) CreateShowtime(c *gin.Context) {
+	slug := c.Param("slug")
+	var req models.ShowtimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	showtime, err := h.showtimeService.CreateShowtime(c.Request.Context(), slug, &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusCreated, "Showtime created successfully", showtime)
+}
+
+func (h *ShowtimeHandler) ListShowtimes(c *gin.Context) {
+	slug := c.Param("slug")
+
+	showtimes, err := h.showtimeService.ListShowtimes(c.Request.Context(), slug)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Showtimes retrieved successfully", showtimes)
+}
+
+func (h *ShowtimeHandler) GetShowtime(c *gin.Context) {
+	slug := c.Param("slug")
+	showtimeID, ok := parseID(c.Param("id"))
+	if !ok {
+		utils.ErrorResponse(c, utils.ErrInvalidShowtimeID)
+		return
+	}
+
+	showtime, err := h.showtimeService.GetShowtime(c.Request.Context(), slug, showtimeID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Showtime retrieved successfully", showtime)
+}
+
+func (h *ShowtimeHandler) UpdateShowtime(c *gin.Context) {
+	slug := c.Param("slug")
+	showtimeID, ok := parseID(c.Param("id"))
+	if !ok {
+		utils.ErrorResponse(c, utils.ErrInvalidShowtimeID)
+		return
+	}
+
+	var req models.ShowtimeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	showtime, err := h.showtimeService.UpdateShowtime(c.Request.Context(), slug, showtimeID, &req)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Showtime updated successfully", showtime)
+}
+
+func (h *ShowtimeHandler) DeleteShowtime(c *gin.Context) {
+	slug := c.Param("slug")
+	showtimeID, ok := parseID(c.Param("id"))
+	if !ok {
+		utils.ErrorResponse(c, utils.ErrInvalidShowtimeID)
+		return
+	}
+
+	err := h.showtimeService.DeleteShowtime(c.Request.Context(), slug, showtimeID)
+	if err != nil {
+		utils.ErrorResponse(c, err)
+		return
+	}
+
+	utils.SuccessResponse(c, http.StatusOK, "Showtime deleted successfully", nil)
+}
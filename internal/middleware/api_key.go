@@ -0,0 +1,64 @@
+package middleware
+
+import (
+	"fmt"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/services"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+const APIKeyHeader = "X-API-Key"
+
+// APIKeyAuth authenticates partner integrations by the X-API-Key header and
+// enforces the per-minute quota of each key.
+type APIKeyAuth struct {
+	apiKeyService services.APIKeyService
+	rateLimiter   *RateLimiter
+}
+
+func NewAPIKeyAuth(apiKeyService services.APIKeyService, rateLimiter *RateLimiter) *APIKeyAuth {
+	return &APIKeyAuth{
+		apiKeyService: apiKeyService,
+		rateLimiter:   rateLimiter,
+	}
+}
+
+// Middleware makes the partner of a valid key the caller of the request.
+// Requests without the header pass through untouched. Rejected keys count
+// against the per-IP limit, so keys cannot be guessed at an unlimited rate.
+func (a *APIKeyAuth) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		secret := c.GetHeader(APIKeyHeader)
+		if secret == "" {
+			c.Next()
+			return
+		}
+
+		ctx := c.Request.Context()
+		key, err := a.apiKeyService.Authenticate(ctx, secret)
+		if err != nil {
+			if !a.rateLimiter.limitClient(c) {
+				return
+			}
+			utils.ErrorResponse(c, err)
+			c.Abort()
+			return
+		}
+
+		if !a.rateLimiter.Limit(c, fmt.Sprintf("rate_limit:api_key:%d", key.ID), key.RateLimit, time.Minute, utils.ErrAPIKeyRateLimitExceeded) {
+			return
+		}
+		a.apiKeyService.RecordUsage(ctx, key.ID)
+
+		c.Set(callerContextKey, &models.Caller{
+			APIKeyID:  key.ID,
+			Role:      models.UserRolePartner,
+			CinemaIDs: key.CinemaIDs,
+		})
+		c.Next()
+	}
+}
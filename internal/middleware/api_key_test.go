@@ -0,0 +1,175 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/repositories"
+	scriptloader "cinema-reservation/internal/scripts"
+	"cinema-reservation/internal/services"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// fakeAPIKeyRepository keeps the keys in memory.
+type fakeAPIKeyRepository struct {
+	repositories.APIKeyRepository
+	keys []*models.APIKey
+}
+
+func (r *fakeAPIKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	key.ID = uint(len(r.keys) + 1)
+	r.keys = append(r.keys, key)
+	return nil
+}
+
+func (r *fakeAPIKeyRepository) GetByID(ctx context.Context, id uint) (*models.APIKey, error) {
+	if id == 0 || int(id) > len(r.keys) {
+		return nil, nil
+	}
+	return r.keys[id-1], nil
+}
+
+func (r *fakeAPIKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	for _, key := range r.keys {
+		if key.KeyHash == hash && key.RevokedAt == nil {
+			return key, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeAPIKeyRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	r.keys[id-1].RevokedAt = &at
+	return nil
+}
+
+func TestAPIKeyAuth(t *testing.T) {
+	cinemaRepo := &fakeCinemaRepository{cinemas: map[string]*models.Cinema{
+		"downtown": {ID: 10, Slug: "downtown"},
+	}}
+	// Quotas and usage counters fail open without Redis, which is all the
+	// authentication needs
+	rdb := redis.NewClient(&redis.Options{Addr: "127.0.0.1:1", MaxRetries: -1})
+	defer rdb.Close()
+
+	apiKeyService := services.NewAPIKeyService(&fakeAPIKeyRepository{}, cinemaRepo, rdb)
+	auth := NewAuth(services.NewAuthService(&fakeUserRepository{}, cinemaRepo, "test-secret", time.Hour))
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rateLimiter := NewRateLimiter(rdb, scripts, 100, 100, time.Minute)
+
+	ctx := context.Background()
+	scoped, err := apiKeyService.CreateKey(ctx, &models.CreateAPIKeyRequest{Name: "Tickets", RateLimit: 10, CinemaSlugs: []string{"downtown"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	revoked, err := apiKeyService.CreateKey(ctx, &models.CreateAPIKeyRequest{Name: "Old", RateLimit: 10})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = apiKeyService.RevokeKey(ctx, revoked.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewAPIKeyAuth(apiKeyService, rateLimiter).Middleware())
+	router.GET("/me", auth.Middleware(), func(c *gin.Context) {
+		caller := CurrentCaller(c)
+		if caller.Role != models.UserRolePartner || caller.APIKeyID != scoped.ID {
+			t.Errorf("caller = %+v, want partner of key %d", caller, scoped.ID)
+		}
+		if !caller.CanBook(10) || caller.CanBook(11) {
+			t.Errorf("key scoped to cinema 10 books %v", caller.CinemaIDs)
+		}
+	})
+	router.GET("/admin", auth.Middleware(), auth.Require(models.UserRoleAdmin), func(c *gin.Context) {})
+
+	tests := []struct {
+		name       string
+		path       string
+		key        string
+		wantStatus int
+		wantCode   string
+	}{
+		{"no key or token", "/me", "", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"unknown key", "/me", "ck_unknown", http.StatusUnauthorized, "INVALID_API_KEY"},
+		{"not a key", "/me", "secret", http.StatusUnauthorized, "INVALID_API_KEY"},
+		{"revoked key", "/me", revoked.Key, http.StatusUnauthorized, "INVALID_API_KEY"},
+		{"valid key", "/me", scoped.Key, http.StatusOK, ""},
+		{"key on admin route", "/admin", scoped.Key, http.StatusForbidden, "INSUFFICIENT_ROLE"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.key != "" {
+				req.Header.Set(APIKeyHeader, tt.key)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var response utils.Response
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil || response.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", response.Code, tt.wantCode)
+			}
+		})
+	}
+}
+
+// TestInvalidAPIKeysAreRateLimited guesses keys from one IP until the per-IP
+// bucket runs out.
+func TestInvalidAPIKeysAreRateLimited(t *testing.T) {
+	const limit = 3
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	apiKeyService := services.NewAPIKeyService(&fakeAPIKeyRepository{}, &fakeCinemaRepository{}, rdb)
+	rateLimiter := NewRateLimiter(rdb, scripts, limit, limit, time.Minute)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(NewAPIKeyAuth(apiKeyService, rateLimiter).Middleware())
+	router.GET("/me", func(c *gin.Context) {})
+
+	// A fresh bucket for every run
+	n := time.Now().UnixNano()
+	ip := fmt.Sprintf("10.%d.%d.%d", n>>16&0xff, n>>8&0xff, n&0xff)
+	t.Cleanup(func() { rdb.Del(context.Background(), "rate_limit:read:"+ip) })
+
+	for i := 0; i <= limit; i++ {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.RemoteAddr = ip + ":1234"
+		req.Header.Set(APIKeyHeader, fmt.Sprintf("ck_guess%d", i))
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+
+		want := http.StatusUnauthorized
+		if i == limit {
+			want = http.StatusTooManyRequests
+		}
+		if w.Code != want {
+			t.Fatalf("guess %d: status = %d, want %d: %s", i+1, w.Code, want, w.Body)
+		}
+	}
+}
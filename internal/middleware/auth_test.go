@@ -0,0 +1,137 @@
+package middleware
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/repositories"
+	"cinema-reservation/internal/services"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeUserRepository knows the users by ID. Methods the policies do not use
+// are left to the embedded nil interface.
+type fakeUserRepository struct {
+	repositories.UserRepository
+	users   map[uint]*models.User
+	cinemas map[uint][]uint
+}
+
+func (r *fakeUserRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	return r.users[id], nil
+}
+
+func (r *fakeUserRepository) ListManagedCinemaIDs(ctx context.Context, userID uint) ([]uint, error) {
+	return r.cinemas[userID], nil
+}
+
+type fakeCinemaRepository struct {
+	repositories.CinemaRepository
+	cinemas map[string]*models.Cinema
+}
+
+func (r *fakeCinemaRepository) GetBySlug(ctx context.Context, slug string) (*models.Cinema, error) {
+	return r.cinemas[slug], nil
+}
+
+func TestAuthPolicies(t *testing.T) {
+	const secret = "test-secret"
+	userRepo := &fakeUserRepository{
+		users: map[uint]*models.User{
+			2: {ID: 2, Role: models.UserRoleStaff},
+			3: {ID: 3, Role: models.UserRoleManager},
+			4: {ID: 4, Role: models.UserRoleAdmin},
+			5: {ID: 5, Role: models.UserRoleCustomer}, // demoted from staff
+		},
+		cinemas: map[uint][]uint{3: {10}},
+	}
+	cinemaRepo := &fakeCinemaRepository{cinemas: map[string]*models.Cinema{
+		"downtown": {ID: 10, Slug: "downtown"},
+		"uptown":   {ID: 11, Slug: "uptown"},
+	}}
+	auth := NewAuth(services.NewAuthService(userRepo, cinemaRepo, secret, time.Hour))
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	ok := func(c *gin.Context) { c.String(http.StatusOK, "%d", CurrentCaller(c).UserID) }
+	router.GET("/me", auth.Middleware(), ok)
+	router.GET("/box-office", auth.Middleware(), auth.Require(models.UserRoleStaff, models.UserRoleAdmin), ok)
+	router.GET("/admin", auth.Middleware(), auth.Require(models.UserRoleAdmin), ok)
+	router.GET("/cinemas/:slug", auth.Middleware(), auth.RequireCinemaManager(), ok)
+	router.GET("/seats", auth.Optional(), func(c *gin.Context) {
+		if CurrentCaller(c) == nil {
+			c.String(http.StatusOK, "anonymous")
+			return
+		}
+		ok(c)
+	})
+
+	token := func(userID string, role models.UserRole) string {
+		signed, err := utils.SignToken([]byte(secret), utils.TokenClaims{
+			Subject:   userID,
+			Role:      string(role),
+			ExpiresAt: time.Now().Add(time.Hour).Unix(),
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return "Bearer " + signed
+	}
+
+	tests := []struct {
+		name          string
+		path          string
+		authorization string
+		wantStatus    int
+		wantCode      string
+	}{
+		{"no token", "/me", "", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"not a bearer token", "/me", "Basic dXNlcjpwYXNz", http.StatusUnauthorized, "UNAUTHORIZED"},
+		{"invalid token", "/me", "Bearer abc.def.ghi", http.StatusUnauthorized, "INVALID_TOKEN"},
+		{"customer", "/me", token("1", models.UserRoleCustomer), http.StatusOK, ""},
+		{"customer on staff route", "/box-office", token("1", models.UserRoleCustomer), http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"staff on staff route", "/box-office", token("2", models.UserRoleStaff), http.StatusOK, ""},
+		{"demoted staff", "/box-office", token("5", models.UserRoleStaff), http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"deleted user", "/box-office", token("9", models.UserRoleStaff), http.StatusUnauthorized, "INVALID_TOKEN"},
+		{"staff on admin route", "/admin", token("2", models.UserRoleStaff), http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"admin on admin route", "/admin", token("4", models.UserRoleAdmin), http.StatusOK, ""},
+		{"role claimed in the token", "/admin", token("2", models.UserRoleAdmin), http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"manager of the cinema", "/cinemas/downtown", token("3", models.UserRoleManager), http.StatusOK, ""},
+		{"manager of another cinema", "/cinemas/uptown", token("3", models.UserRoleManager), http.StatusForbidden, "CINEMA_NOT_MANAGED"},
+		{"admin on any cinema", "/cinemas/uptown", token("4", models.UserRoleAdmin), http.StatusOK, ""},
+		{"staff on a cinema", "/cinemas/downtown", token("2", models.UserRoleStaff), http.StatusForbidden, "INSUFFICIENT_ROLE"},
+		{"unknown cinema", "/cinemas/nowhere", token("4", models.UserRoleAdmin), http.StatusNotFound, "CINEMA_NOT_FOUND"},
+		{"anonymous on public route", "/seats", "", http.StatusOK, ""},
+		{"customer on public route", "/seats", token("1", models.UserRoleCustomer), http.StatusOK, ""},
+		{"invalid token on public route", "/seats", "Bearer abc.def.ghi", http.StatusUnauthorized, "INVALID_TOKEN"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set("Authorization", tt.authorization)
+			}
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			if tt.wantCode == "" {
+				return
+			}
+			var response utils.Response
+			err := json.Unmarshal(w.Body.Bytes(), &response)
+			if err != nil || response.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", response.Code, tt.wantCode)
+			}
+		})
+	}
+}
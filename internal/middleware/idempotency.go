@@ -0,0 +1,246 @@
+package middleware
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"cinema-reservation/internal/models"
+	scriptloader "cinema-reservation/internal/scripts"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"
+	idempotentReplayedHeader = "Idempotent-Replayed"
+	maxIdempotencyKeyLength  = 255
+	// idempotencyInProgressTTL bounds how long a key stays claimed by a
+	// request that never finished, e.g. because its instance crashed. The
+	// marker is refreshed while the request runs, so slow requests keep it.
+	idempotencyInProgressTTL = 30 * time.Second
+)
+
+const (
+	idempotencyStateRunning   = "in_progress"
+	idempotencyStateCompleted = "completed"
+)
+
+// idempotencyRecord is what is stored in Redis for an idempotency key: a
+// marker while the first request runs, then its response.
+type idempotencyRecord struct {
+	State       string `json:"state"`
+	Fingerprint string `json:"fingerprint"`
+	Claim       string `json:"claim,omitempty"` // tells the markers of retries apart
+	StatusCode  int    `json:"status_code,omitempty"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// Idempotency replays the response of the first request for a repeated
+// Idempotency-Key header, so a client retrying after a timeout learns the
+// outcome of its booking instead of running it again.
+type Idempotency struct {
+	redis         *redis.Client
+	scripts       *scriptloader.Registry
+	ttl           time.Duration
+	inProgressTTL time.Duration
+}
+
+func NewIdempotency(redis *redis.Client, scripts *scriptloader.Registry, ttl time.Duration) *Idempotency {
+	return &Idempotency{
+		redis:         redis,
+		scripts:       scripts,
+		ttl:           ttl,
+		inProgressTTL: idempotencyInProgressTTL,
+	}
+}
+
+func (i *Idempotency) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		idempotencyKey := c.GetHeader(IdempotencyKeyHeader)
+		if idempotencyKey == "" {
+			c.Next()
+			return
+		}
+		if len(idempotencyKey) > maxIdempotencyKeyLength {
+			utils.ErrorResponse(c, utils.ErrInvalidIdempotencyKey)
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.ErrorResponse(c, utils.ErrInvalidInput)
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		// Keys are per user, one customer cannot replay another one's response
+		owner := "anonymous"
+		if caller := CurrentCaller(c); caller != nil && caller.Role == models.UserRolePartner {
+			owner = "api_key:" + strconv.FormatUint(uint64(caller.APIKeyID), 10)
+		} else if caller != nil {
+			owner = strconv.FormatUint(uint64(caller.UserID), 10)
+		}
+
+		ctx := c.Request.Context()
+		key := "idempotency:" + owner + ":" + c.Request.Method + ":" + c.FullPath() + ":" + idempotencyKey
+		fingerprint := requestFingerprint(body)
+
+		// Claim the key. The marker expires on its own if this instance dies
+		// before storing the response.
+		claim, err := utils.RandomToken(8)
+		if err != nil {
+			logrus.WithError(err).Error("failed to generate idempotency claim")
+			utils.ErrorResponse(c, utils.ErrInternalServer)
+			c.Abort()
+			return
+		}
+		marker, _ := json.Marshal(idempotencyRecord{State: idempotencyStateRunning, Fingerprint: fingerprint, Claim: claim})
+		claimed, err := i.redis.SetNX(ctx, key, marker, i.inProgressTTL).Result()
+		if err != nil {
+			// If Redis is down, run the request without protection
+			logrus.WithError(err).Warn("idempotency key not checked")
+			c.Next()
+			return
+		}
+
+		if !claimed {
+			i.replay(c, key, fingerprint)
+			return
+		}
+
+		// The outcome must be stored even when the client hung up meanwhile,
+		// or its retry would find the key in progress until the marker expires
+		ctx = context.WithoutCancel(ctx)
+
+		stop := make(chan struct{})
+		refreshed := make(chan struct{})
+		go func() {
+			defer close(refreshed)
+			i.keepClaim(ctx, key, marker, stop)
+		}()
+
+		writer := &responseBodyWriter{ResponseWriter: c.Writer, body: &bytes.Buffer{}}
+		c.Writer = writer
+		c.Next()
+
+		close(stop)
+		<-refreshed
+
+		// Server errors leave nothing behind, so the client may try again
+		if writer.Status() >= http.StatusInternalServerError {
+			i.release(ctx, key, marker, idempotencyKey)
+			return
+		}
+
+		record, _ := json.Marshal(idempotencyRecord{
+			State:       idempotencyStateCompleted,
+			Fingerprint: fingerprint,
+			StatusCode:  writer.Status(),
+			ContentType: writer.Header().Get("Content-Type"),
+			Body:        writer.body.Bytes(),
+		})
+		updated, err := i.update(ctx, key, marker, record, i.ttl)
+		if err != nil {
+			// Without the response, free the key for a retry rather than
+			// answering it as in progress
+			logrus.WithError(err).Errorf("failed to store response of idempotency key %s", idempotencyKey)
+			i.release(ctx, key, marker, idempotencyKey)
+			return
+		}
+		if !updated {
+			logrus.Warnf("idempotency key %s was claimed by another request, response not stored", idempotencyKey)
+		}
+	}
+}
+
+// keepClaim extends the marker of a running request until stop is closed, so
+// a retry keeps getting a conflict however long the request takes.
+func (i *Idempotency) keepClaim(ctx context.Context, key string, marker []byte, stop <-chan struct{}) {
+	ticker := time.NewTicker(i.inProgressTTL / 3)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+			kept, err := i.update(ctx, key, marker, marker, i.inProgressTTL)
+			if err != nil {
+				logrus.WithError(err).Warn("failed to refresh idempotency key")
+			} else if !kept {
+				return
+			}
+		}
+	}
+}
+
+// release frees the key for a retry if this request still holds it.
+func (i *Idempotency) release(ctx context.Context, key string, marker []byte, idempotencyKey string) {
+	_, err := i.update(ctx, key, marker, nil, 0)
+	if err != nil {
+		logrus.WithError(err).Errorf("failed to release idempotency key %s", idempotencyKey)
+	}
+}
+
+// update replaces the marker of this request with value, or deletes the key
+// when value is empty, and reports whether the key still had the marker.
+func (i *Idempotency) update(ctx context.Context, key string, marker, value []byte, ttl time.Duration) (bool, error) {
+	updated, err := i.scripts.Run(ctx, scriptloader.IdempotencyUpdate, []string{key}, marker, value, ttl.Milliseconds()).Int()
+	return updated == 1, err
+}
+
+// replay answers a repeated key with the stored response, or with a conflict
+// while the first request is still running.
+func (i *Idempotency) replay(c *gin.Context, key, fingerprint string) {
+	defer c.Abort()
+
+	data, err := i.redis.Get(c.Request.Context(), key).Bytes()
+	if err == redis.Nil {
+		// The first request failed or its marker expired in between
+		utils.ErrorResponse(c, utils.ErrIdempotencyKeyInProgress)
+		return
+	}
+	if err != nil {
+		logrus.WithError(err).Error("failed to read idempotency key")
+		utils.ErrorResponse(c, utils.ErrInternalServer)
+		return
+	}
+
+	var record idempotencyRecord
+	err = json.Unmarshal(data, &record)
+	if err != nil {
+		logrus.WithError(err).Error("malformed idempotency record")
+		utils.ErrorResponse(c, utils.ErrInternalServer)
+		return
+	}
+
+	if record.Fingerprint != fingerprint {
+		utils.ErrorResponse(c, utils.ErrIdempotencyKeyReused)
+		return
+	}
+	if record.State != idempotencyStateCompleted {
+		utils.ErrorResponse(c, utils.ErrIdempotencyKeyInProgress)
+		return
+	}
+
+	c.Header(idempotentReplayedHeader, "true")
+	c.Data(record.StatusCode, record.ContentType, record.Body)
+}
+
+func requestFingerprint(body []byte) string {
+	sum := sha256.Sum256(body)
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,197 @@
+package middleware
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	scriptloader "cinema-reservation/internal/scripts"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newIdempotentRouter serves POST /reservations behind the middleware,
+// answering with status and counting the requests that reach the handler.
+// It needs a Redis server in TEST_REDIS_URL.
+func newIdempotentRouter(t *testing.T, status *int, calls *int32, release <-chan struct{}) *gin.Engine {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/reservations", newTestIdempotency(t).Middleware(), func(c *gin.Context) {
+		n := atomic.AddInt32(calls, 1)
+		if release != nil {
+			<-release
+		}
+		c.JSON(*status, gin.H{"call": n})
+	})
+	return router
+}
+
+// newTestIdempotency needs a Redis server in TEST_REDIS_URL.
+func newTestIdempotency(t *testing.T) *Idempotency {
+	t.Helper()
+
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewIdempotency(rdb, scripts, time.Minute)
+}
+
+func postWithKey(router *gin.Engine, key, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(body))
+	req.Header.Set(IdempotencyKeyHeader, key)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	return w
+}
+
+func testIdempotencyKey(t *testing.T) string {
+	return fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	status, calls := http.StatusOK, int32(0)
+	router := newIdempotentRouter(t, &status, &calls, nil)
+	key := testIdempotencyKey(t)
+
+	first := postWithKey(router, key, `{"seats":[1]}`)
+	status = http.StatusConflict
+	second := postWithKey(router, key, `{"seats":[1]}`)
+
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+	if second.Code != http.StatusOK || second.Body.String() != first.Body.String() {
+		t.Errorf("replay = %d %s, want %d %s", second.Code, second.Body, first.Code, first.Body)
+	}
+	if second.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("replay is missing the %s header", idempotentReplayedHeader)
+	}
+
+	other := postWithKey(router, key, `{"seats":[2]}`)
+	if other.Code != http.StatusUnprocessableEntity {
+		t.Errorf("other body = %d, want %d", other.Code, http.StatusUnprocessableEntity)
+	}
+}
+
+func TestIdempotencyForgetsServerErrors(t *testing.T) {
+	status, calls := http.StatusInternalServerError, int32(0)
+	router := newIdempotentRouter(t, &status, &calls, nil)
+	key := testIdempotencyKey(t)
+
+	postWithKey(router, key, `{}`)
+	status = http.StatusOK
+	retry := postWithKey(router, key, `{}`)
+
+	if calls != 2 || retry.Code != http.StatusOK {
+		t.Errorf("retry = %d after %d calls, want 200 after 2 calls", retry.Code, calls)
+	}
+}
+
+func TestIdempotencyRejectsConcurrentDuplicate(t *testing.T) {
+	status, calls := http.StatusOK, int32(0)
+	release := make(chan struct{})
+	router := newIdempotentRouter(t, &status, &calls, release)
+	key := testIdempotencyKey(t)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postWithKey(router, key, `{}`) }()
+	for atomic.LoadInt32(&calls) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	duplicate := postWithKey(router, key, `{}`)
+	close(release)
+	first := <-done
+
+	if duplicate.Code != http.StatusConflict {
+		t.Errorf("duplicate = %d, want %d", duplicate.Code, http.StatusConflict)
+	}
+	if first.Code != http.StatusOK {
+		t.Errorf("first = %d, want %d", first.Code, http.StatusOK)
+	}
+}
+
+func TestIdempotencyStoresResponseAfterClientLeft(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/reservations", newTestIdempotency(t).Middleware(), func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"code": "ABC123"})
+		// The client disconnects once the booking is committed
+		cancel()
+	})
+	key := testIdempotencyKey(t)
+
+	req := httptest.NewRequest(http.MethodPost, "/reservations", strings.NewReader(`{}`)).WithContext(ctx)
+	req.Header.Set(IdempotencyKeyHeader, key)
+	router.ServeHTTP(httptest.NewRecorder(), req)
+
+	retry := postWithKey(router, key, `{}`)
+	if retry.Code != http.StatusOK || retry.Header().Get(idempotentReplayedHeader) != "true" {
+		t.Errorf("retry = %d %s, want the replayed response", retry.Code, retry.Body)
+	}
+}
+
+func TestIdempotencyKeepsClaimOfSlowRequests(t *testing.T) {
+	idempotency := newTestIdempotency(t)
+	idempotency.inProgressTTL = 300 * time.Millisecond
+	release := make(chan struct{})
+	var calls int32
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/reservations", idempotency.Middleware(), func(c *gin.Context) {
+		atomic.AddInt32(&calls, 1)
+		<-release
+		c.JSON(http.StatusOK, gin.H{"code": "ABC123"})
+	})
+	key := testIdempotencyKey(t)
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postWithKey(router, key, `{}`) }()
+
+	// Well past the TTL of the first marker
+	time.Sleep(3 * idempotency.inProgressTTL)
+	duplicate := postWithKey(router, key, `{}`)
+	close(release)
+	<-done
+
+	if duplicate.Code != http.StatusConflict {
+		t.Errorf("duplicate of a slow request = %d, want %d", duplicate.Code, http.StatusConflict)
+	}
+	if calls != 1 {
+		t.Errorf("handler ran %d times, want 1", calls)
+	}
+}
+
+func TestIdempotencyStoresOnlyOverItsOwnClaim(t *testing.T) {
+	idempotency := newTestIdempotency(t)
+	key := testIdempotencyKey(t)
+	redisKey := "idempotency:anonymous:POST:/reservations:" + key
+	ctx := context.Background()
+	t.Cleanup(func() { idempotency.redis.Del(ctx, redisKey) })
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/reservations", idempotency.Middleware(), func(c *gin.Context) {
+		// The claim was lost and another request took the key
+		idempotency.redis.Set(ctx, redisKey, "other", time.Minute)
+		c.JSON(http.StatusOK, gin.H{"code": "ABC123"})
+	})
+
+	postWithKey(router, key, `{}`)
+
+	if got := idempotency.redis.Get(ctx, redisKey).Val(); got != "other" {
+		t.Errorf("key = %q, want the claim of the other request kept", got)
+	}
+}
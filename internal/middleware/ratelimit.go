@@ -1,57 +1,161 @@
 package middleware
 
 import (
+	"context"
+	"net/http"
+	"strconv"
 	"time"
 
+	"cinema-reservation/internal/models"
+	scriptloader "cinema-reservation/internal/scripts"
 	"cinema-reservation/internal/utils"
 
 	"github.com/gin-gonic/gin"
 	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
 )
 
+const (
+	rateLimitLimitHeader     = "RateLimit-Limit"
+	rateLimitRemainingHeader = "RateLimit-Remaining"
+	rateLimitResetHeader     = "RateLimit-Reset"
+	retryAfterHeader         = "Retry-After"
+)
+
+// RateLimiter limits requests with a token bucket per client in Redis. A
+// client may burst up to the limit, after which the bucket refills at the
+// limit per window. Taking a token is a single Lua script, so concurrent
+// requests cannot pass the limit together.
 type RateLimiter struct {
-	redis  *redis.Client
-	limit  int
-	window time.Duration
+	redis      *redis.Client
+	scripts    *scriptloader.Registry
+	readLimit  int
+	writeLimit int
+	window     time.Duration
 }
 
-func NewRateLimiter(redis *redis.Client, limit int, window time.Duration) *RateLimiter {
+// rateLimitResult is the state of a bucket after taking a token.
+type rateLimitResult struct {
+	allowed    bool
+	limit      int
+	remaining  int
+	reset      time.Duration // until the bucket is full again
+	retryAfter time.Duration // until the next token, when denied
+}
+
+// NewRateLimiter limits every client to readLimit GET requests and
+// writeLimit other requests per window.
+func NewRateLimiter(redis *redis.Client, scripts *scriptloader.Registry, readLimit, writeLimit int, window time.Duration) *RateLimiter {
 	return &RateLimiter{
-		redis:  redis,
-		limit:  limit,
-		window: window,
+		redis:      redis,
+		scripts:    scripts,
+		readLimit:  readLimit,
+		writeLimit: writeLimit,
+		window:     window,
 	}
 }
 
+// Middleware limits requests per client IP, with separate buckets for reads
+// and writes. Requests with an API key are left to the quota of the key.
 func (rl *RateLimiter) Middleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		key := "rate_limit:" + c.ClientIP()
-
-		// Get current count
-		count, err := rl.redis.Get(c.Request.Context(), key).Int()
-		if err != nil && err != redis.Nil {
-			// If Redis is down, allow the request
+		if isPartner(c) {
 			c.Next()
 			return
 		}
 
-		if count >= rl.limit {
-			utils.ErrorResponse(c, utils.ErrRateLimitExceeded)
-			c.Abort()
+		if !rl.limitClient(c) {
 			return
 		}
 
-		// Increment counter
-		pipe := rl.redis.Pipeline()
-		pipe.Incr(c.Request.Context(), key)
-		pipe.Expire(c.Request.Context(), key, rl.window)
-		_, err = pipe.Exec(c.Request.Context())
-		if err != nil {
-			// If Redis is down, allow the request
+		c.Next()
+	}
+}
+
+// limitClient takes a token from the read or write bucket of the client IP.
+func (rl *RateLimiter) limitClient(c *gin.Context) bool {
+	bucket, limit := "write", rl.writeLimit
+	if c.Request.Method == http.MethodGet || c.Request.Method == http.MethodHead {
+		bucket, limit = "read", rl.readLimit
+	}
+
+	return rl.Limit(c, "rate_limit:"+bucket+":"+c.ClientIP(), limit, rl.window, utils.ErrRateLimitExceeded)
+}
+
+// Route adds a bucket of its own to a route, on top of the read or write
+// bucket, for endpoints that need a stricter limit.
+func (rl *RateLimiter) Route(name string, limit int, window time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isPartner(c) {
 			c.Next()
 			return
 		}
 
+		if !rl.Limit(c, "rate_limit:"+name+":"+c.ClientIP(), limit, window, utils.ErrRateLimitExceeded) {
+			return
+		}
+
 		c.Next()
 	}
 }
+
+// Limit takes a token from the bucket in key and reports the bucket in the
+// RateLimit headers. A request over the limit is aborted with err.
+func (rl *RateLimiter) Limit(c *gin.Context, key string, limit int, window time.Duration, err error) bool {
+	result, takeErr := rl.take(c.Request.Context(), key, limit, window)
+	if takeErr != nil {
+		// If Redis is down, allow the request
+		logrus.WithError(takeErr).Warn("rate limit not checked")
+		return true
+	}
+
+	setRateLimitHeaders(c, result)
+	if !result.allowed {
+		c.Header(retryAfterHeader, strconv.Itoa(ceilSeconds(result.retryAfter)))
+		utils.ErrorResponse(c, err)
+		c.Abort()
+		return false
+	}
+	return true
+}
+
+func (rl *RateLimiter) take(ctx context.Context, key string, limit int, window time.Duration) (*rateLimitResult, error) {
+	values, err := rl.scripts.Run(ctx, scriptloader.RateLimit, []string{key}, limit, window.Milliseconds(), time.Now().UnixMilli()).Int64Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	return &rateLimitResult{
+		allowed:    values[0] == 1,
+		limit:      limit,
+		remaining:  int(values[1]),
+		reset:      time.Duration(values[2]) * time.Millisecond,
+		retryAfter: time.Duration(values[3]) * time.Millisecond,
+	}, nil
+}
+
+// setRateLimitHeaders reports the bucket closest to its limit when several
+// apply to a request.
+func setRateLimitHeaders(c *gin.Context, result *rateLimitResult) {
+	header := c.Writer.Header()
+	if current := header.Get(rateLimitRemainingHeader); current != "" {
+		remaining, err := strconv.Atoi(current)
+		if err == nil && remaining < result.remaining {
+			return
+		}
+	}
+
+	header.Set(rateLimitLimitHeader, strconv.Itoa(result.limit))
+	header.Set(rateLimitRemainingHeader, strconv.Itoa(result.remaining))
+	header.Set(rateLimitResetHeader, strconv.Itoa(ceilSeconds(result.reset)))
+}
+
+func isPartner(c *gin.Context) bool {
+	caller := CurrentCaller(c)
+	return caller != nil && caller.Role == models.UserRolePartner
+}
+
+// ceilSeconds rounds up, so a client waiting that long is never early.
+func ceilSeconds(d time.Duration) int {
+	return int((d + time.Second - 1) / time.Second)
+}
@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	scriptloader "cinema-reservation/internal/scripts"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-redis/redis/v8"
+)
+
+// testRedis connects to the Redis server in TEST_REDIS_URL, skipping the
+// test without one.
+func testRedis(t *testing.T) *redis.Client {
+	t.Helper()
+
+	redisURL := os.Getenv("TEST_REDIS_URL")
+	if redisURL == "" {
+		t.Skip("TEST_REDIS_URL is not set")
+	}
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	rdb := redis.NewClient(opt)
+	t.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func newRateLimitedRouter(t *testing.T, limit int, window time.Duration) *gin.Engine {
+	t.Helper()
+
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rateLimiter := NewRateLimiter(rdb, scripts, 1000, 1000, time.Minute)
+
+	// A fresh bucket for every run
+	name := fmt.Sprintf("%s-%d", t.Name(), time.Now().UnixNano())
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.POST("/reservations", rateLimiter.Route(name, limit, window), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return router
+}
+
+func TestRateLimiterConcurrentBurst(t *testing.T) {
+	const limit, requests = 10, 100
+	router := newRateLimitedRouter(t, limit, time.Minute)
+
+	var (
+		wg     sync.WaitGroup
+		mu     sync.Mutex
+		counts = make(map[int]int)
+	)
+	fire := make(chan struct{})
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-fire
+			w := httptest.NewRecorder()
+			router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reservations", nil))
+			mu.Lock()
+			counts[w.Code]++
+			mu.Unlock()
+		}()
+	}
+	close(fire)
+	wg.Wait()
+
+	if counts[http.StatusOK] != limit || counts[http.StatusTooManyRequests] != requests-limit {
+		t.Errorf("got %v, want %d allowed and %d limited", counts, limit, requests-limit)
+	}
+}
+
+func TestRateLimiterHeaders(t *testing.T) {
+	router := newRateLimitedRouter(t, 2, time.Minute)
+
+	post := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reservations", nil))
+		return w
+	}
+
+	w := post()
+	if w.Header().Get("RateLimit-Limit") != "2" || w.Header().Get("RateLimit-Remaining") != "1" {
+		t.Errorf("headers after the first request = %v", w.Header())
+	}
+	post()
+
+	w = post()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("RateLimit-Remaining = %q, want 0", w.Header().Get("RateLimit-Remaining"))
+	}
+	// One of two tokens per minute comes back after 30 seconds
+	retryAfter, err := strconv.Atoi(w.Header().Get("Retry-After"))
+	if err != nil || retryAfter < 1 || retryAfter > 30 {
+		t.Errorf("Retry-After = %q, want 1 to 30 seconds", w.Header().Get("Retry-After"))
+	}
+	reset, err := strconv.Atoi(w.Header().Get("RateLimit-Reset"))
+	if err != nil || reset < 30 || reset > 60 {
+		t.Errorf("RateLimit-Reset = %q, want 30 to 60 seconds", w.Header().Get("RateLimit-Reset"))
+	}
+}
+
+func TestRateLimiterRefills(t *testing.T) {
+	router := newRateLimitedRouter(t, 1, 200*time.Millisecond)
+
+	post := func() int {
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/reservations", nil))
+		return w.Code
+	}
+
+	if code := post(); code != http.StatusOK {
+		t.Fatalf("first request: status = %d", code)
+	}
+	if code := post(); code != http.StatusTooManyRequests {
+		t.Fatalf("second request: status = %d, want %d", code, http.StatusTooManyRequests)
+	}
+	time.Sleep(250 * time.Millisecond)
+	if code := post(); code != http.StatusOK {
+		t.Errorf("after the window: status = %d, want %d", code, http.StatusOK)
+	}
+}
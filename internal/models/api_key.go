@@ -0,0 +1,48 @@
+package models
+
+import (
+	"time"
+)
+
+// APIKey lets a partner integration call the API without a user account.
+// Only a hash of the key is stored; the key itself is shown once, when it is
+// issued.
+type APIKey struct {
+	ID      uint   `json:"id" gorm:"primaryKey"`
+	Name    string `json:"name" gorm:"not null"`
+	Prefix  string `json:"prefix" gorm:"size:16;not null"` // start of the key, to tell keys apart
+	KeyHash string `json:"-" gorm:"size:64;not null;uniqueIndex"`
+	// RateLimit is how many requests the key may make per minute
+	RateLimit int `json:"rate_limit" gorm:"not null"`
+	// CinemaIDs are the cinemas the key may book, every cinema when empty
+	CinemaIDs []uint     `json:"cinema_ids" gorm:"-"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+}
+
+// APIKeyCinema puts a cinema in the scope of an API key.
+type APIKeyCinema struct {
+	APIKeyID uint   `gorm:"primaryKey"`
+	CinemaID uint   `gorm:"primaryKey"`
+	APIKey   APIKey `gorm:"foreignKey:APIKeyID;constraint:OnDelete:CASCADE"`
+	Cinema   Cinema `gorm:"foreignKey:CinemaID;constraint:OnDelete:CASCADE"`
+}
+
+type CreateAPIKeyRequest struct {
+	Name      string `json:"name" binding:"required,trimmed_min=1"`
+	RateLimit int    `json:"rate_limit" binding:"required,min=1"`
+	// CinemaSlugs limits the key to these cinemas, empty for every cinema
+	CinemaSlugs []string `json:"cinema_slugs"`
+}
+
+// IssuedAPIKey is a new key with its secret, which is not shown again.
+type IssuedAPIKey struct {
+	*APIKey
+	Key string `json:"key"`
+}
+
+// APIKeyUsage is the number of requests a key made on a day (UTC).
+type APIKeyUsage struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+}
@@ -5,14 +5,94 @@ import (
 )
 
 type Cinema struct {
-	ID          uint      `json:"id" gorm:"primaryKey"`
-	Name        string    `json:"name" gorm:"not null;unique"`
-	Slug        string    `json:"slug" gorm:"not null;unique;index"`
-	Rows        int       `json:"rows" gorm:"not null"`
-	Columns     int       `json:"columns" gorm:"not null"`
-	MinDistance int       `json:"min_distance" gorm:"not null"`
-	CreatedAt   time.Time `json:"created_at"`
-	UpdatedAt   time.Time `json:"updated_at"`
+	ID          uint   `json:"id" gorm:"primaryKey"`
+	Name        string `json:"name" gorm:"not null;unique"`
+	Slug        string `json:"slug" gorm:"not null;unique;index"`
+	Rows        int    `json:"rows" gorm:"not null"`
+	Columns     int    `json:"columns" gorm:"not null"`
+	MinDistance int    `json:"min_distance" gorm:"not null"`
+	// DistancePolicy measures MinDistance, Manhattan by default
+	DistancePolicy DistancePolicy `json:"distance_policy" gorm:"size:20;not null;default:'manhattan'"`
+	// Layout is empty for a plain Rows x Columns rectangle of seats
+	Layout           Layout `json:"layout,omitempty" gorm:"type:text;serializer:json"`
+	BlocksSpanAisles bool   `json:"blocks_span_aisles" gorm:"not null;default:false"`
+	// WheelchairReleaseMinutes is how long before a showtime its wheelchair
+	// spaces go on general sale, 0 keeps them for wheelchair users only
+	WheelchairReleaseMinutes int       `json:"wheelchair_release_minutes" gorm:"not null;default:0"`
+	CreatedAt                time.Time `json:"created_at"`
+	UpdatedAt                time.Time `json:"updated_at"`
+}
+
+// HasSeat reports whether there is a sellable seat at the position.
+func (c *Cinema) HasSeat(row, column int) bool {
+	if row < 0 || row >= c.Rows || column < 0 || column >= c.Columns {
+		return false
+	}
+	_, ok := layoutSeatCategories[c.Layout.Cell(row, column)]
+	return ok
+}
+
+// SeatCategory returns the class of the seat at the position.
+func (c *Cinema) SeatCategory(row, column int) SeatCategory {
+	return layoutSeatCategories[c.Layout.Cell(row, column)]
+}
+
+// Cells of a layout row.
+const (
+	LayoutSeat       = 'S'
+	LayoutPremium    = 'P'
+	LayoutVIP        = 'V'
+	LayoutCouple     = 'L' // loveseat
+	LayoutWheelchair = 'W'
+	LayoutCompanion  = 'C' // seat next to a wheelchair space
+	LayoutAisle      = 'A'
+	LayoutNone       = 'X' // gap, staircase, pillar or the missing end of a curved row
+)
+
+// layoutSeatCategories maps the seat cells of a layout to their category.
+var layoutSeatCategories = map[byte]SeatCategory{
+	LayoutSeat:       SeatCategoryStandard,
+	LayoutPremium:    SeatCategoryPremium,
+	LayoutVIP:        SeatCategoryVIP,
+	LayoutCouple:     SeatCategoryCouple,
+	LayoutWheelchair: SeatCategoryWheelchair,
+	LayoutCompanion:  SeatCategoryCompanion,
+}
+
+// Layout describes an irregular auditorium with one string per row and one
+// character per column, e.g. "XSSSASSSX".
+type Layout []string
+
+// Cell returns the kind of the cell, treating an empty layout as all
+// standard seats.
+func (l Layout) Cell(row, column int) byte {
+	if len(l) == 0 {
+		return LayoutSeat
+	}
+	return l[row][column]
+}
+
+// Valid reports whether the layout is a non-empty rectangle made of known
+// cells with at least one seat.
+func (l Layout) Valid() bool {
+	if len(l) == 0 || len(l[0]) == 0 {
+		return false
+	}
+
+	hasSeat := false
+	for _, row := range l {
+		if len(row) != len(l[0]) {
+			return false
+		}
+		for i := 0; i < len(row); i++ {
+			if _, ok := layoutSeatCategories[row[i]]; ok {
+				hasSeat = true
+			} else if row[i] != LayoutAisle && row[i] != LayoutNone {
+				return false
+			}
+		}
+	}
+	return hasSeat
 }
 
 type Seat struct {
@@ -20,13 +100,117 @@ type Seat struct {
 	Column int `json:"column"`
 }
 
+// SeatState is the state of a single cell in a seat map.
+type SeatState string
+
+const (
+	SeatAvailable         SeatState = "available"
+	SeatReserved          SeatState = "reserved"
+	SeatHeld              SeatState = "held"
+	SeatBlockedByDistance SeatState = "blocked_by_distance"
+	SeatDisabled          SeatState = "disabled" // no sellable seat at this position
+	SeatAisle             SeatState = "aisle"
+)
+
+// SeatMap is the full rows x columns grid of a showtime, indexed as
+// Seats[row][column].
+type SeatMap struct {
+	ShowtimeID uint          `json:"showtime_id"`
+	Rows       int           `json:"rows"`
+	Columns    int           `json:"columns"`
+	Seats      [][]SeatState `json:"seats"`
+	// Categories is empty for cells without a seat
+	Categories [][]SeatCategory `json:"categories"`
+	Prices     []SeatPrice      `json:"prices"`
+}
+
+// SeatRecommendation is a block of adjacent seats for a group, scored from 0
+// to 1 where higher is better.
+type SeatRecommendation struct {
+	Seats []Seat  `json:"seats"`
+	Score float64 `json:"score"`
+}
+
+// SeatBlockShape is the arrangement of the seats of a group.
+type SeatBlockShape string
+
+const (
+	BlockShapeRow          SeatBlockShape = "row"
+	BlockShapeRectangle    SeatBlockShape = "rectangle"     // e.g. 2x2 or 2x3
+	BlockShapeAnyConnected SeatBlockShape = "any-connected" // every seat next to or behind another
+)
+
+func (s SeatBlockShape) Valid() bool {
+	return s == BlockShapeRow || s == BlockShapeRectangle || s == BlockShapeAnyConnected
+}
+
+// SeatBlockFormat is the encoding of the available seat blocks.
+type SeatBlockFormat string
+
+const (
+	BlockFormatSeats SeatBlockFormat = "seats" // every block as its list of seats
+	BlockFormatRuns  SeatBlockFormat = "runs"  // overlapping row blocks merged into SeatRuns
+)
+
+func (f SeatBlockFormat) Valid() bool {
+	return f == BlockFormatSeats || f == BlockFormatRuns
+}
+
+// AvailableSeatsQuery narrows the seat blocks offered to a group.
+type AvailableSeatsQuery struct {
+	GroupSize     int
+	Shape         SeatBlockShape
+	Accessibility AccessibilityNeed
+	PartyCode     string
+	Row           *int // only blocks with a seat in this row
+	Format        SeatBlockFormat
+	Limit         int // 0 for every block
+	Cursor        string
+}
+
+// SeatRun is a stretch of a row in which a group can start at every seat
+// that leaves room for the whole group before the end of the run. Aisle
+// columns inside a run are counted in Length but are not seats.
+type SeatRun struct {
+	Row         int `json:"row"`
+	StartColumn int `json:"start_column"`
+	Length      int `json:"length"`
+}
+
+// AvailableSeats is a page of available seat blocks, in Blocks or Runs
+// depending on the format. NextCursor is empty on the last page.
+type AvailableSeats struct {
+	Blocks     [][]Seat
+	Runs       []SeatRun
+	NextCursor string
+}
+
 type CheckSeatsRequest struct {
 	Seats []SeatRequest `json:"seats" binding:"required,min=1,dive,required"`
 }
 
+// CreateCinemaRequest describes the hall either as a rows x columns
+// rectangle or, for irregular halls, as a layout.
 type CreateCinemaRequest struct {
-	Name        string `json:"name" binding:"required,trimmed_min=5"`
-	Rows        int    `json:"rows" binding:"required,min=1"`
-	Columns     int    `json:"columns" binding:"required,min=1"`
-	MinDistance int    `json:"min_distance" binding:"required,min=0"`
+	Name                     string         `json:"name" binding:"required,trimmed_min=5"`
+	Rows                     int            `json:"rows" binding:"required_without=Layout,omitempty,min=1"`
+	Columns                  int            `json:"columns" binding:"required_without=Layout,omitempty,min=1"`
+	MinDistance              int            `json:"min_distance" binding:"required,min=0"`
+	DistancePolicy           DistancePolicy `json:"distance_policy" binding:"omitempty,oneof=manhattan chebyshev euclidean row_only checkerboard"`
+	Layout                   Layout         `json:"layout" binding:"omitempty,min=1"`
+	BlocksSpanAisles         bool           `json:"blocks_span_aisles"`
+	WheelchairReleaseMinutes int            `json:"wheelchair_release_minutes" binding:"min=0"`
+}
+
+// AccessibilityNeed is the seating need a booking is made for, empty for
+// general sale.
+type AccessibilityNeed string
+
+const (
+	AccessibilityNone       AccessibilityNeed = ""
+	AccessibilityWheelchair AccessibilityNeed = "wheelchair"
+)
+
+func (n AccessibilityNeed) Valid() bool {
+	return n == AccessibilityNone || n == AccessibilityWheelchair
 }
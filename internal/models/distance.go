@@ -0,0 +1,46 @@
+package models
+
+// DistancePolicy decides which seats around a taken seat are too close to be
+// sold. reserve.lua implements the same rules, keep the two in sync.
+type DistancePolicy string
+
+const (
+	// DistanceManhattan counts rows plus columns.
+	DistanceManhattan DistancePolicy = "manhattan"
+	// DistanceChebyshev counts the larger of rows and columns, so it also
+	// blocks the diagonals.
+	DistanceChebyshev DistancePolicy = "chebyshev"
+	// DistanceEuclidean is the straight line distance.
+	DistanceEuclidean DistancePolicy = "euclidean"
+	// DistanceRowOnly only keeps seats empty to the left and right.
+	DistanceRowOnly DistancePolicy = "row_only"
+	// DistanceCheckerboard keeps seats empty to the left and right and in
+	// front and behind, but not on the diagonals, so rows fill in a
+	// staggered checkerboard.
+	DistanceCheckerboard DistancePolicy = "checkerboard"
+)
+
+// TooClose reports whether a seat rowOffset rows and columnOffset columns
+// away from a taken seat is closer than minDistance.
+func (p DistancePolicy) TooClose(rowOffset, columnOffset, minDistance int) bool {
+	dr, dc := rowOffset, columnOffset
+	if dr < 0 {
+		dr = -dr
+	}
+	if dc < 0 {
+		dc = -dc
+	}
+
+	switch p {
+	case DistanceChebyshev:
+		return max(dr, dc) < minDistance
+	case DistanceEuclidean:
+		return dr*dr+dc*dc < minDistance*minDistance
+	case DistanceRowOnly:
+		return dr == 0 && dc < minDistance
+	case DistanceCheckerboard:
+		return (dr == 0 || dc == 0) && dr+dc < minDistance
+	default:
+		return dr+dc < minDistance
+	}
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+)
+
+// Hold is a temporary claim on seats that lives only in Redis until it is
+// confirmed into a Reservation or expires.
+type Hold struct {
+	ID         string    `json:"id"`
+	ShowtimeID uint      `json:"showtime_id"`
+	Seats      []Seat    `json:"seats"`
+	ExpiresAt  time.Time `json:"expires_at"`
+	// PartyID is the party the reservation joins once the hold is confirmed
+	PartyID *uint `json:"party_id,omitempty"`
+}
+
+type HoldRequest struct {
+	ShowtimeID    uint              `json:"showtime_id" binding:"required"`
+	Seats         []SeatRequest     `json:"seats" binding:"required,min=1,dive,required"`
+	Accessibility AccessibilityNeed `json:"accessibility" binding:"omitempty,oneof=wheelchair"`
+	// PartyCode is the booking code of a reservation to sit with, like in
+	// ReservationRequest
+	PartyCode string `json:"party_code"`
+}
+
+type ConfirmHoldRequest struct {
+	Note string `json:"note"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+type Movie struct {
+	ID              uint      `json:"id" gorm:"primaryKey"`
+	Title           string    `json:"title" gorm:"not null;unique"`
+	Slug            string    `json:"slug" gorm:"not null;unique;index"`
+	DurationMinutes int       `json:"duration_minutes" gorm:"not null"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type CreateMovieRequest struct {
+	Title           string `json:"title" binding:"required,trimmed_min=1"`
+	DurationMinutes int    `json:"duration_minutes" binding:"required,min=1"`
+}
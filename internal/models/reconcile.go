@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	SeatFixRestored = "restored" // reserved in the DB, missing in Redis
+	SeatFixReleased = "released" // reserved in Redis, not in the DB
+)
+
+// SeatFix is a single seat the reconciler repaired in Redis.
+type SeatFix struct {
+	ShowtimeID uint   `json:"showtime_id"`
+	Row        int    `json:"row"`
+	Column     int    `json:"column"`
+	Action     string `json:"action"`
+}
+
+// ReconcileReport summarizes one pass of the Redis/Postgres reconciler.
+type ReconcileReport struct {
+	StartedAt        time.Time     `json:"started_at"`
+	Duration         time.Duration `json:"duration"`
+	ShowtimesChecked int           `json:"showtimes_checked"`
+	SeatsRestored    int           `json:"seats_restored"`
+	SeatsReleased    int           `json:"seats_released"`
+	Fixes            []SeatFix     `json:"fixes,omitempty"`
+}
+
+// ResyncReport summarizes a rebuild of the Redis seat hashes from the DB.
+type ResyncReport struct {
+	ShowtimesRebuilt int           `json:"showtimes_rebuilt"`
+	SeatsSynced      int           `json:"seats_synced"`
+	Duration         time.Duration `json:"duration"`
+}
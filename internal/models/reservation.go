@@ -10,21 +10,32 @@ import (
 
 type Reservation struct {
 	ID         uint           `json:"id" gorm:"primaryKey"`
+	Code       string         `json:"code" gorm:"size:8;uniqueIndex"`
 	CinemaID   uint           `json:"cinema_id" gorm:"not null"`
+	ShowtimeID uint           `json:"showtime_id" gorm:"not null;index"`
 	Note       string         `json:"note"`
+	UserID     *uint          `json:"user_id,omitempty" gorm:"index"`    // owner, nil for reservations made before accounts
+	APIKeyID   *uint          `json:"api_key_id,omitempty" gorm:"index"` // partner key the reservation was made with
+	PartyID    *uint          `json:"party_id,omitempty" gorm:"index"`   // first reservation of the party it joined
+	Total      int64          `json:"total" gorm:"not null;default:0"`   // sum of the active seat prices
 	ReservedAt time.Time      `json:"reserved_at" gorm:"default:CURRENT_TIMESTAMP"`
 	Cinema     Cinema         `json:"-" gorm:"foreignKey:CinemaID"`
+	Showtime   Showtime       `json:"-" gorm:"foreignKey:ShowtimeID"`
 	Seats      []ReservedSeat `json:"seats,omitempty" gorm:"foreignKey:ReservationID;constraint:OnDelete:CASCADE"`
 	DeletedAt  gorm.DeletedAt `json:"-"` // Soft delete
 }
 
 type ReservedSeat struct {
 	ID            uint           `json:"id" gorm:"primaryKey"`
-	CinemaID      uint           `json:"cinema_id" gorm:"not null;uniqueIndex:idx_cinema_seat,unique,where:deleted_at IS NULL"`
+	CinemaID      uint           `json:"cinema_id" gorm:"not null;index"`
+	ShowtimeID    uint           `json:"showtime_id" gorm:"not null;uniqueIndex:idx_showtime_seat,unique,where:deleted_at IS NULL"`
 	ReservationID uint           `json:"reservation_id" gorm:"not null"`
-	Row           int            `json:"row" gorm:"not null;uniqueIndex:idx_cinema_seat,unique,where:deleted_at IS NULL"`
-	Column        int            `json:"column" gorm:"not null;uniqueIndex:idx_cinema_seat,unique,where:deleted_at IS NULL"`
+	Row           int            `json:"row" gorm:"not null;uniqueIndex:idx_showtime_seat,unique,where:deleted_at IS NULL"`
+	Column        int            `json:"column" gorm:"not null;uniqueIndex:idx_showtime_seat,unique,where:deleted_at IS NULL"`
+	Category      SeatCategory   `json:"category" gorm:"size:20;not null;default:'standard'"`
+	Price         int64          `json:"price" gorm:"not null;default:0"`
 	Cinema        Cinema         `json:"-" gorm:"foreignKey:CinemaID"`
+	Showtime      Showtime       `json:"-" gorm:"foreignKey:ShowtimeID"`
 	DeletedAt     gorm.DeletedAt `json:"-"` // Soft delete
 }
 
@@ -39,18 +50,35 @@ func (seats ReservedSeats) String() string {
 	return strings.Join(seatStrings, ", ")
 }
 
+func (seats ReservedSeats) Seats() []Seat {
+	result := make([]Seat, 0, len(seats))
+	for _, seat := range seats {
+		result = append(result, Seat{Row: seat.Row, Column: seat.Column})
+	}
+
+	return result
+}
+
 type SeatRequest struct {
 	Row    int `json:"row" binding:"min=0"`
 	Column int `json:"column" binding:"min=0"`
 }
 
 type ReservationRequest struct {
-	CinemaSlug string        `json:"cinema_slug" binding:"required"`
-	Note       string        `json:"note"`
-	Seats      []SeatRequest `json:"seats" binding:"required,min=1,dive,required"`
+	ShowtimeID    uint              `json:"showtime_id" binding:"required"`
+	Note          string            `json:"note"`
+	Seats         []SeatRequest     `json:"seats" binding:"required,min=1,dive,required"`
+	Accessibility AccessibilityNeed `json:"accessibility" binding:"omitempty,oneof=wheelchair"`
+	// PartyCode is the booking code of a reservation to sit with, exempting
+	// the new seats from the distance rule towards its party
+	PartyCode string `json:"party_code"`
 }
 
 type CancelRequest struct {
-	CinemaSlug string        `json:"cinema_slug" binding:"required"`
+	ShowtimeID uint          `json:"showtime_id" binding:"required"`
 	Seats      []SeatRequest `json:"seats" binding:"required,min=1,dive,required"`
 }
+
+type CancelReservationSeatsRequest struct {
+	Seats []SeatRequest `json:"seats" binding:"required,min=1,dive,required"`
+}
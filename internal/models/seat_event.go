@@ -0,0 +1,30 @@
+package models
+
+const (
+	SeatEventReserved  = "reserved"
+	SeatEventHeld      = "held"
+	SeatEventConfirmed = "confirmed"
+	SeatEventReleased  = "released"
+	SeatEventResynced  = "resynced"
+)
+
+// SeatEvent is published on Redis pub/sub whenever seats of a showtime change.
+type SeatEvent struct {
+	ShowtimeID uint   `json:"showtime_id"`
+	Type       string `json:"type"`
+	Seats      []Seat `json:"seats,omitempty"`
+}
+
+// SeatStateChange is a single cell of a seat map that changed state.
+type SeatStateChange struct {
+	Row    int       `json:"row"`
+	Column int       `json:"column"`
+	State  SeatState `json:"state"`
+}
+
+// SeatMapDelta lists the cells of a seat map that changed since the last
+// snapshot or delta sent to a client.
+type SeatMapDelta struct {
+	ShowtimeID uint              `json:"showtime_id"`
+	Seats      []SeatStateChange `json:"seats"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// SeatCategory is the class of a seat, set per cell by the cinema layout.
+type SeatCategory string
+
+const (
+	SeatCategoryStandard   SeatCategory = "standard"
+	SeatCategoryPremium    SeatCategory = "premium"
+	SeatCategoryVIP        SeatCategory = "vip"
+	SeatCategoryCouple     SeatCategory = "couple"
+	SeatCategoryWheelchair SeatCategory = "wheelchair"
+	SeatCategoryCompanion  SeatCategory = "companion"
+)
+
+// SeatPrice is the price of a seat category in a cinema, in the minor unit
+// of the currency (e.g. cents).
+type SeatPrice struct {
+	ID        uint         `json:"-" gorm:"primaryKey"`
+	CinemaID  uint         `json:"-" gorm:"not null;uniqueIndex:idx_cinema_category"`
+	Category  SeatCategory `json:"category" gorm:"size:20;not null;uniqueIndex:idx_cinema_category"`
+	Amount    int64        `json:"amount" gorm:"not null"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+type SeatPriceRequest struct {
+	Category SeatCategory `json:"category" binding:"required,oneof=standard premium vip couple wheelchair companion"`
+	Amount   int64        `json:"amount" binding:"min=0"`
+}
+
+// SetSeatPricesRequest replaces the whole price table of a cinema.
+type SetSeatPricesRequest struct {
+	Prices []SeatPriceRequest `json:"prices" binding:"required,min=1,dive"`
+}
@@ -0,0 +1,31 @@
+package models
+
+import (
+	"time"
+)
+
+const (
+	SeatReleasePending = "pending"
+	SeatReleaseDead    = "dead"
+)
+
+const (
+	SeatReleaseReasonRollback = "rollback" // reservation insert failed after Redis accepted the seats
+	SeatReleaseReasonCancel   = "cancel"   // seats were canceled in the DB but Redis still holds them
+)
+
+// SeatRelease is an outbox entry for freeing seats in Redis that failed the
+// first time. A worker retries pending entries with exponential backoff and
+// moves them to the dead state once the attempts are used up.
+type SeatRelease struct {
+	ID            uint      `json:"id" gorm:"primaryKey"`
+	ShowtimeID    uint      `json:"showtime_id" gorm:"not null;index"`
+	Seats         []Seat    `json:"seats" gorm:"not null;serializer:json"`
+	Reason        string    `json:"reason" gorm:"not null"`
+	Status        string    `json:"status" gorm:"not null;index:idx_seat_release_due"`
+	Attempts      int       `json:"attempts" gorm:"not null"`
+	NextAttemptAt time.Time `json:"next_attempt_at" gorm:"not null;index:idx_seat_release_due"`
+	LastError     string    `json:"last_error"`
+	CreatedAt     time.Time `json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
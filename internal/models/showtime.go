@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Showtime is a single screening of a movie in a cinema. Seat inventory is
+// scoped to a showtime, so the same auditorium can be sold again for every
+// screening.
+type Showtime struct {
+	ID        uint           `json:"id" gorm:"primaryKey"`
+	CinemaID  uint           `json:"cinema_id" gorm:"not null;index"`
+	MovieID   uint           `json:"movie_id" gorm:"not null;index"`
+	StartsAt  time.Time      `json:"starts_at" gorm:"not null;index"`
+	EndsAt    time.Time      `json:"ends_at" gorm:"not null"`
+	Cinema    Cinema         `json:"-" gorm:"foreignKey:CinemaID"`
+	Movie     *Movie         `json:"movie,omitempty" gorm:"foreignKey:MovieID"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `json:"-"` // Soft delete
+}
+
+type ShowtimeRequest struct {
+	MovieID  uint      `json:"movie_id" binding:"required"`
+	StartsAt time.Time `json:"starts_at" binding:"required"`
+}
@@ -0,0 +1,135 @@
+package models
+
+import (
+	"time"
+)
+
+// UserRole decides what a user may do:
+//   - customers manage their own reservations,
+//   - staff (the box office) view and cancel every reservation,
+//   - managers also run the cinemas they manage: prices, showtimes and resyncs,
+//   - admins can do everything, including creating cinemas and assigning roles.
+type UserRole string
+
+const (
+	UserRoleCustomer UserRole = "customer"
+	UserRoleStaff    UserRole = "staff"
+	UserRoleManager  UserRole = "manager"
+	UserRoleAdmin    UserRole = "admin"
+	// UserRolePartner is the role of requests made with an API key, it is
+	// never given to a user
+	UserRolePartner UserRole = "partner"
+)
+
+func (r UserRole) Valid() bool {
+	switch r {
+	case UserRoleCustomer, UserRoleStaff, UserRoleManager, UserRoleAdmin:
+		return true
+	}
+	return false
+}
+
+// CinemaManager gives a manager access to a cinema.
+type CinemaManager struct {
+	UserID   uint   `gorm:"primaryKey"`
+	CinemaID uint   `gorm:"primaryKey"`
+	User     User   `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
+	Cinema   Cinema `gorm:"foreignKey:CinemaID;constraint:OnDelete:CASCADE"`
+}
+
+type User struct {
+	ID           uint      `json:"id" gorm:"primaryKey"`
+	Email        string    `json:"email" gorm:"size:255;not null;uniqueIndex"`
+	Name         string    `json:"name"`
+	PasswordHash string    `json:"-" gorm:"not null"`
+	Role         UserRole  `json:"role" gorm:"size:20;not null;default:'customer'"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+type RegisterRequest struct {
+	Email string `json:"email" binding:"required,email,max=255"`
+	Name  string `json:"name"`
+	// bcrypt ignores everything after 72 bytes
+	Password string `json:"password" binding:"required,min=8,max=72"`
+}
+
+type LoginRequest struct {
+	Email    string `json:"email" binding:"required"`
+	Password string `json:"password" binding:"required"`
+}
+
+type SetUserRoleRequest struct {
+	Role UserRole `json:"role" binding:"required,oneof=customer staff manager admin"`
+	// CinemaSlugs are the cinemas a manager manages, ignored for other roles
+	CinemaSlugs []string `json:"cinema_slugs"`
+}
+
+type AuthToken struct {
+	Token     string    `json:"token"`
+	ExpiresAt time.Time `json:"expires_at"`
+	User      *User     `json:"user"`
+}
+
+// Caller is the authenticated user, or partner API key, making a request.
+type Caller struct {
+	UserID   uint
+	APIKeyID uint
+	Role     UserRole
+	// CinemaIDs are the cinemas a manager manages, or the cinemas a partner
+	// may book (every cinema when empty)
+	CinemaIDs []uint
+}
+
+// HasRole reports whether the caller has one of the roles.
+func (c *Caller) HasRole(roles ...UserRole) bool {
+	for _, role := range roles {
+		if c.Role == role {
+			return true
+		}
+	}
+	return false
+}
+
+// ManagesCinema reports whether the caller may run the cinema.
+func (c *Caller) ManagesCinema(cinemaID uint) bool {
+	if c.Role == UserRoleAdmin {
+		return true
+	}
+	if c.Role != UserRoleManager {
+		return false
+	}
+	for _, id := range c.CinemaIDs {
+		if id == cinemaID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanBook reports whether the caller may reserve and hold seats in the
+// cinema. Only partners are limited.
+func (c *Caller) CanBook(cinemaID uint) bool {
+	if c.Role != UserRolePartner || len(c.CinemaIDs) == 0 {
+		return true
+	}
+	for _, id := range c.CinemaIDs {
+		if id == cinemaID {
+			return true
+		}
+	}
+	return false
+}
+
+// CanAccess reports whether the caller may view and cancel the reservation.
+// Reservations made before accounts existed have no owner and are left to
+// staff.
+func (c *Caller) CanAccess(reservation *Reservation) bool {
+	if c.HasRole(UserRoleStaff, UserRoleAdmin) || c.ManagesCinema(reservation.CinemaID) {
+		return true
+	}
+	if c.Role == UserRolePartner {
+		return reservation.APIKeyID != nil && *reservation.APIKeyID == c.APIKeyID
+	}
+	return reservation.UserID != nil && *reservation.UserID == c.UserID
+}
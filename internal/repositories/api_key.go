@@ -0,0 +1,107 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cinema-reservation/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+// Create stores the key together with its cinema scope.
+func (r *apiKeyRepository) Create(ctx context.Context, key *models.APIKey) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(key).Error
+		if err != nil {
+			return err
+		}
+		if len(key.CinemaIDs) == 0 {
+			return nil
+		}
+
+		scope := make([]models.APIKeyCinema, len(key.CinemaIDs))
+		for i, cinemaID := range key.CinemaIDs {
+			scope[i] = models.APIKeyCinema{APIKeyID: key.ID, CinemaID: cinemaID}
+		}
+		return tx.Create(&scope).Error
+	})
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uint) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).First(&key, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, r.loadCinemaIDs(ctx, []*models.APIKey{&key})
+}
+
+// GetByHash finds a key that has not been revoked by the hash of its secret.
+func (r *apiKeyRepository) GetByHash(ctx context.Context, hash string) (*models.APIKey, error) {
+	var key models.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ? AND revoked_at IS NULL", hash).First(&key).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &key, r.loadCinemaIDs(ctx, []*models.APIKey{&key})
+}
+
+func (r *apiKeyRepository) List(ctx context.Context) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.WithContext(ctx).Order("id").Find(&keys).Error
+	if err != nil {
+		return nil, err
+	}
+
+	pointers := make([]*models.APIKey, len(keys))
+	for i := range keys {
+		pointers[i] = &keys[i]
+	}
+	return keys, r.loadCinemaIDs(ctx, pointers)
+}
+
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uint, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&models.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		Update("revoked_at", at).Error
+}
+
+func (r *apiKeyRepository) loadCinemaIDs(ctx context.Context, keys []*models.APIKey) error {
+	if len(keys) == 0 {
+		return nil
+	}
+
+	byID := make(map[uint]*models.APIKey, len(keys))
+	ids := make([]uint, 0, len(keys))
+	for _, key := range keys {
+		key.CinemaIDs = []uint{}
+		byID[key.ID] = key
+		ids = append(ids, key.ID)
+	}
+
+	var scope []models.APIKeyCinema
+	err := r.db.WithContext(ctx).Where("api_key_id IN ?", ids).Order("cinema_id").Find(&scope).Error
+	if err != nil {
+		return err
+	}
+	for _, entry := range scope {
+		key := byID[entry.APIKeyID]
+		key.CinemaIDs = append(key.CinemaIDs, entry.CinemaID)
+	}
+	return nil
+}
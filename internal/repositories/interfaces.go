@@ -2,6 +2,7 @@ package repositories
 
 import (
 	"context"
+	"time"
 
 	"cinema-reservation/internal/models"
 )
@@ -13,9 +14,65 @@ type CinemaRepository interface {
 	ExistsByName(ctx context.Context, name string) (bool, error)
 }
 
+type MovieRepository interface {
+	Create(ctx context.Context, movie *models.Movie) error
+	GetByID(ctx context.Context, id uint) (*models.Movie, error)
+	List(ctx context.Context) ([]models.Movie, error)
+	ExistsByTitle(ctx context.Context, title string) (bool, error)
+}
+
+type ShowtimeRepository interface {
+	Create(ctx context.Context, showtime *models.Showtime) error
+	Update(ctx context.Context, showtime *models.Showtime) error
+	Delete(ctx context.Context, id uint) error
+	GetByID(ctx context.Context, id uint) (*models.Showtime, error)
+	ListByCinema(ctx context.Context, cinemaID uint) ([]models.Showtime, error)
+	HasOverlap(ctx context.Context, cinemaID uint, startsAt, endsAt time.Time, excludeID uint) (bool, error)
+}
+
 type ReservationRepository interface {
 	Create(ctx context.Context, reservation *models.Reservation) error
-	FindReservedSeats(ctx context.Context, cinemaID uint, seats []models.Seat) ([]models.ReservedSeat, error)
+	GetByID(ctx context.Context, id uint) (*models.Reservation, error)
+	GetByCode(ctx context.Context, code string) (*models.Reservation, error)
+	ListByUser(ctx context.Context, userID uint) ([]models.Reservation, error)
+	ListByAPIKey(ctx context.Context, apiKeyID uint) ([]models.Reservation, error)
+	ExistsByCode(ctx context.Context, code string) (bool, error)
+	GetPartySeats(ctx context.Context, partyID uint) ([]models.ReservedSeat, error)
+	FindReservedSeats(ctx context.Context, showtimeID uint, seats []models.Seat) ([]models.ReservedSeat, error)
+	CountReservedSeats(ctx context.Context, showtimeID uint) (int64, error)
 	CancelSeats(ctx context.Context, seatIDs []uint) error
 	GetAllReservedSeats(ctx context.Context) ([]models.ReservedSeat, error)
+	GetReservedSeatsByShowtime(ctx context.Context, showtimeID uint) ([]models.ReservedSeat, error)
+	ListReservedShowtimeIDs(ctx context.Context, endsAfter time.Time) ([]uint, error)
+}
+
+type UserRepository interface {
+	Create(ctx context.Context, user *models.User) error
+	GetByID(ctx context.Context, id uint) (*models.User, error)
+	GetByEmail(ctx context.Context, email string) (*models.User, error)
+	ExistsByEmail(ctx context.Context, email string) (bool, error)
+	ListManagedCinemaIDs(ctx context.Context, userID uint) ([]uint, error)
+	SetRole(ctx context.Context, userID uint, role models.UserRole, cinemaIDs []uint) error
+}
+
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *models.APIKey) error
+	GetByID(ctx context.Context, id uint) (*models.APIKey, error)
+	GetByHash(ctx context.Context, hash string) (*models.APIKey, error)
+	List(ctx context.Context) ([]models.APIKey, error)
+	Revoke(ctx context.Context, id uint, at time.Time) error
+}
+
+type SeatPriceRepository interface {
+	ListByCinema(ctx context.Context, cinemaID uint) ([]models.SeatPrice, error)
+	ReplaceForCinema(ctx context.Context, cinemaID uint, prices []models.SeatPrice) error
+}
+
+type SeatReleaseRepository interface {
+	Create(ctx context.Context, release *models.SeatRelease) error
+	GetByID(ctx context.Context, id uint) (*models.SeatRelease, error)
+	List(ctx context.Context, status string) ([]models.SeatRelease, error)
+	ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.SeatRelease, error)
+	Update(ctx context.Context, release *models.SeatRelease) error
+	Delete(ctx context.Context, id uint) error
 }
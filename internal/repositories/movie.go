@@ -0,0 +1,48 @@
+package repositories
+
+import (
+	"context"
+
+	"cinema-reservation/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type movieRepository struct {
+	db *gorm.DB
+}
+
+func NewMovieRepository(db *gorm.DB) MovieRepository {
+	return &movieRepository{db: db}
+}
+
+func (r *movieRepository) Create(ctx context.Context, movie *models.Movie) error {
+	return r.db.WithContext(ctx).Create(movie).Error
+}
+
+func (r *movieRepository) GetByID(ctx context.Context, id uint) (*models.Movie, error) {
+	var movie models.Movie
+	err := r.db.WithContext(ctx).First(&movie, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &movie, nil
+}
+
+func (r *movieRepository) List(ctx context.Context) ([]models.Movie, error) {
+	var movies []models.Movie
+	err := r.db.WithContext(ctx).Order("title").Find(&movies).Error
+	return movies, err
+}
+
+func (r *movieRepository) ExistsByTitle(ctx context.Context, title string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.Movie{}).Where("title = ?", title).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
@@ -3,6 +3,7 @@ package repositories
 import (
 	"context"
 	"log"
+	"time"
 
 	"cinema-reservation/internal/models"
 
@@ -28,14 +29,77 @@ func (r *reservationRepository) Create(ctx context.Context, reservation *models.
 	})
 }
 
-func (r *reservationRepository) FindReservedSeats(ctx context.Context, cinemaID uint, seats []models.Seat) ([]models.ReservedSeat, error) {
+// GetByID loads a reservation with its active seats.
+func (r *reservationRepository) GetByID(ctx context.Context, id uint) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := r.db.WithContext(ctx).Preload("Seats").First(&reservation, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// GetByCode loads a reservation by its booking code with its active seats.
+func (r *reservationRepository) GetByCode(ctx context.Context, code string) (*models.Reservation, error) {
+	var reservation models.Reservation
+	err := r.db.WithContext(ctx).Preload("Seats").Where("code = ?", code).First(&reservation).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &reservation, nil
+}
+
+// ListByUser loads the reservations of the user with their active seats, the
+// newest first.
+func (r *reservationRepository) ListByUser(ctx context.Context, userID uint) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Preload("Seats").Where("user_id = ?", userID).Order("reserved_at DESC, id DESC").Find(&reservations).Error
+	return reservations, err
+}
+
+// ListByAPIKey loads the reservations made with the API key with their active
+// seats, the newest first.
+func (r *reservationRepository) ListByAPIKey(ctx context.Context, apiKeyID uint) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	err := r.db.WithContext(ctx).Preload("Seats").Where("api_key_id = ?", apiKeyID).Order("reserved_at DESC, id DESC").Find(&reservations).Error
+	return reservations, err
+}
+
+// GetPartySeats returns the active seats of every reservation of the party.
+func (r *reservationRepository) GetPartySeats(ctx context.Context, partyID uint) ([]models.ReservedSeat, error) {
+	var seats []models.ReservedSeat
+	err := r.db.WithContext(ctx).
+		Where("reservation_id IN (?)", r.db.Model(&models.Reservation{}).
+			Select("id").
+			Where("id = ? OR party_id = ?", partyID, partyID)).
+		Find(&seats).Error
+	return seats, err
+}
+
+func (r *reservationRepository) ExistsByCode(ctx context.Context, code string) (bool, error) {
+	var count int64
+	// Count canceled reservations too, a code is never handed out twice
+	err := r.db.WithContext(ctx).Unscoped().Model(&models.Reservation{}).Where("code = ?", code).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *reservationRepository) FindReservedSeats(ctx context.Context, showtimeID uint, seats []models.Seat) ([]models.ReservedSeat, error) {
 	if len(seats) == 0 {
 		return []models.ReservedSeat{}, nil
 	}
 
 	var reservedSeats []models.ReservedSeat
 
-	query := r.db.WithContext(ctx).Where("cinema_id = ?", cinemaID)
+	query := r.db.WithContext(ctx).Where("showtime_id = ?", showtimeID)
 
 	var orConditions []string
 	var args []interface{}
@@ -65,18 +129,54 @@ func (r *reservationRepository) FindReservedSeats(ctx context.Context, cinemaID
 	return reservedSeats, nil
 }
 
+func (r *reservationRepository) CountReservedSeats(ctx context.Context, showtimeID uint) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.ReservedSeat{}).Where("showtime_id = ?", showtimeID).Count(&count).Error
+	return count, err
+}
+
 func (r *reservationRepository) CancelSeats(ctx context.Context, seatIDs []uint) error {
 	if len(seatIDs) == 0 {
 		return nil
 	}
 
 	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var reservationIDs []uint
+		err := tx.Model(&models.ReservedSeat{}).
+			Where("id IN ?", seatIDs).
+			Distinct().
+			Pluck("reservation_id", &reservationIDs).Error
+		if err != nil {
+			return err
+		}
+
 		// Soft delete all seats in one operation
 		result := tx.Delete(&models.ReservedSeat{}, seatIDs)
 		if result.Error != nil {
 			return result.Error
 		}
 
+		// Totals only count the seats that are still reserved
+		result = tx.Model(&models.Reservation{}).
+			Where("id IN ?", reservationIDs).
+			Update("total", tx.Model(&models.ReservedSeat{}).
+				Select("COALESCE(SUM(price), 0)").
+				Where("reserved_seats.reservation_id = reservations.id"))
+		if result.Error != nil {
+			return result.Error
+		}
+
+		// Reservations without any seat left are canceled as a whole
+		result = tx.
+			Where("id IN ?", reservationIDs).
+			Where("NOT EXISTS (?)", tx.Model(&models.ReservedSeat{}).
+				Select("1").
+				Where("reserved_seats.reservation_id = reservations.id")).
+			Delete(&models.Reservation{})
+		if result.Error != nil {
+			return result.Error
+		}
+
 		return nil
 	})
 }
@@ -91,3 +191,22 @@ func (r *reservationRepository) GetAllReservedSeats(ctx context.Context) ([]mode
 
 	return reservedSeats, nil
 }
+
+func (r *reservationRepository) GetReservedSeatsByShowtime(ctx context.Context, showtimeID uint) ([]models.ReservedSeat, error) {
+	var reservedSeats []models.ReservedSeat
+	err := r.db.WithContext(ctx).Where("showtime_id = ?", showtimeID).Find(&reservedSeats).Error
+	return reservedSeats, err
+}
+
+// ListReservedShowtimeIDs returns the showtimes ending after endsAfter that
+// have at least one active reserved seat.
+func (r *reservationRepository) ListReservedShowtimeIDs(ctx context.Context, endsAfter time.Time) ([]uint, error) {
+	var showtimeIDs []uint
+	err := r.db.WithContext(ctx).
+		Model(&models.ReservedSeat{}).
+		Joins("JOIN showtimes ON showtimes.id = reserved_seats.showtime_id").
+		Where("showtimes.ends_at > ?", endsAfter).
+		Distinct().
+		Pluck("reserved_seats.showtime_id", &showtimeIDs).Error
+	return showtimeIDs, err
+}
@@ -0,0 +1,38 @@
+package repositories
+
+import (
+	"context"
+
+	"cinema-reservation/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type seatPriceRepository struct {
+	db *gorm.DB
+}
+
+func NewSeatPriceRepository(db *gorm.DB) SeatPriceRepository {
+	return &seatPriceRepository{db: db}
+}
+
+func (r *seatPriceRepository) ListByCinema(ctx context.Context, cinemaID uint) ([]models.SeatPrice, error) {
+	var prices []models.SeatPrice
+	err := r.db.WithContext(ctx).Where("cinema_id = ?", cinemaID).Order("category").Find(&prices).Error
+	return prices, err
+}
+
+// ReplaceForCinema swaps the whole price table of a cinema in one transaction.
+func (r *seatPriceRepository) ReplaceForCinema(ctx context.Context, cinemaID uint, prices []models.SeatPrice) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("cinema_id = ?", cinemaID).Delete(&models.SeatPrice{}).Error
+		if err != nil {
+			return err
+		}
+
+		for i := range prices {
+			prices[i].CinemaID = cinemaID
+		}
+		return tx.Create(&prices).Error
+	})
+}
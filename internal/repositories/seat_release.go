@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cinema-reservation/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type seatReleaseRepository struct {
+	db *gorm.DB
+}
+
+func NewSeatReleaseRepository(db *gorm.DB) SeatReleaseRepository {
+	return &seatReleaseRepository{db: db}
+}
+
+func (r *seatReleaseRepository) Create(ctx context.Context, release *models.SeatRelease) error {
+	return r.db.WithContext(ctx).Create(release).Error
+}
+
+func (r *seatReleaseRepository) GetByID(ctx context.Context, id uint) (*models.SeatRelease, error) {
+	var release models.SeatRelease
+	err := r.db.WithContext(ctx).First(&release, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &release, nil
+}
+
+// List returns the releases with the given status, or all of them when status
+// is empty.
+func (r *seatReleaseRepository) List(ctx context.Context, status string) ([]models.SeatRelease, error) {
+	var releases []models.SeatRelease
+	query := r.db.WithContext(ctx).Order("id")
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+	err := query.Find(&releases).Error
+	return releases, err
+}
+
+// ClaimDue picks up to limit pending releases whose next attempt is due and
+// pushes their next attempt to leaseUntil, so other instances running the
+// worker skip them while they are processed.
+func (r *seatReleaseRepository) ClaimDue(ctx context.Context, now, leaseUntil time.Time, limit int) ([]models.SeatRelease, error) {
+	var releases []models.SeatRelease
+	err := r.db.WithContext(ctx).Raw(`
+		UPDATE seat_releases SET next_attempt_at = ?, updated_at = ?
+		WHERE id IN (
+			SELECT id FROM seat_releases
+			WHERE status = ? AND next_attempt_at <= ?
+			ORDER BY next_attempt_at
+			LIMIT ?
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING *`,
+		leaseUntil, now, models.SeatReleasePending, now, limit,
+	).Scan(&releases).Error
+	return releases, err
+}
+
+func (r *seatReleaseRepository) Update(ctx context.Context, release *models.SeatRelease) error {
+	return r.db.WithContext(ctx).Save(release).Error
+}
+
+func (r *seatReleaseRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.SeatRelease{}, id).Error
+}
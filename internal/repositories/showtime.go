@@ -0,0 +1,74 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"cinema-reservation/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type showtimeRepository struct {
+	db *gorm.DB
+}
+
+func NewShowtimeRepository(db *gorm.DB) ShowtimeRepository {
+	return &showtimeRepository{db: db}
+}
+
+func (r *showtimeRepository) Create(ctx context.Context, showtime *models.Showtime) error {
+	return r.db.WithContext(ctx).Create(showtime).Error
+}
+
+func (r *showtimeRepository) Update(ctx context.Context, showtime *models.Showtime) error {
+	return r.db.WithContext(ctx).
+		Model(showtime).
+		Select("MovieID", "StartsAt", "EndsAt").
+		Updates(showtime).Error
+}
+
+func (r *showtimeRepository) Delete(ctx context.Context, id uint) error {
+	return r.db.WithContext(ctx).Delete(&models.Showtime{}, id).Error
+}
+
+// GetByID loads the showtime together with its cinema and movie.
+func (r *showtimeRepository) GetByID(ctx context.Context, id uint) (*models.Showtime, error) {
+	var showtime models.Showtime
+	err := r.db.WithContext(ctx).
+		Preload("Cinema").
+		Preload("Movie").
+		First(&showtime, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &showtime, nil
+}
+
+func (r *showtimeRepository) ListByCinema(ctx context.Context, cinemaID uint) ([]models.Showtime, error) {
+	var showtimes []models.Showtime
+	err := r.db.WithContext(ctx).
+		Preload("Movie").
+		Where("cinema_id = ?", cinemaID).
+		Order("starts_at").
+		Find(&showtimes).Error
+	return showtimes, err
+}
+
+// HasOverlap reports whether another showtime in the cinema intersects the
+// [startsAt, endsAt) interval. excludeID is skipped so a showtime does not
+// overlap with itself when it is being updated.
+func (r *showtimeRepository) HasOverlap(ctx context.Context, cinemaID uint, startsAt, endsAt time.Time, excludeID uint) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&models.Showtime{}).
+		Where("cinema_id = ? AND starts_at < ? AND ends_at > ? AND id <> ?", cinemaID, endsAt, startsAt, excludeID).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
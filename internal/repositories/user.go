@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+
+	"cinema-reservation/internal/models"
+
+	"gorm.io/gorm"
+)
+
+type userRepository struct {
+	db *gorm.DB
+}
+
+func NewUserRepository(db *gorm.DB) UserRepository {
+	return &userRepository{db: db}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	return r.db.WithContext(ctx).Create(user).Error
+}
+
+func (r *userRepository) GetByID(ctx context.Context, id uint) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).First(&user, id).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	var user models.User
+	err := r.db.WithContext(ctx).Where("email = ?", email).First(&user).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (r *userRepository) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&models.User{}).Where("email = ?", email).Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	return count > 0, nil
+}
+
+func (r *userRepository) ListManagedCinemaIDs(ctx context.Context, userID uint) ([]uint, error) {
+	var cinemaIDs []uint
+	err := r.db.WithContext(ctx).Model(&models.CinemaManager{}).Where("user_id = ?", userID).Order("cinema_id").Pluck("cinema_id", &cinemaIDs).Error
+	return cinemaIDs, err
+}
+
+// SetRole changes the role of the user and replaces the cinemas it manages.
+func (r *userRepository) SetRole(ctx context.Context, userID uint, role models.UserRole, cinemaIDs []uint) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.User{}).Where("id = ?", userID).Update("role", role).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("user_id = ?", userID).Delete(&models.CinemaManager{}).Error
+		if err != nil {
+			return err
+		}
+		if len(cinemaIDs) == 0 {
+			return nil
+		}
+
+		managers := make([]models.CinemaManager, len(cinemaIDs))
+		for i, cinemaID := range cinemaIDs {
+			managers[i] = models.CinemaManager{UserID: userID, CinemaID: cinemaID}
+		}
+		return tx.Create(&managers).Error
+	})
+}
@@ -1,11 +1,18 @@
 -- cancel.lua
--- Cancel reserved seats by removing them from the Redis set
+-- Cancel reserved seats by removing them from the Redis hash. Only seats
+-- still marked as reserved ("1") are freed: a release retried after the seat
+-- was sold or held again must not take it from its new customer.
 
--- KEYS[1] = key where reserved seats are stored (SET)
+-- KEYS[1] = key where reserved seats are stored (HASH)
 -- ARGV = list of seat coordinates row:column
+-- Returns the seats that were freed
 
+local released = {}
 for i = 1, #ARGV do
-  redis.call("HDEL", KEYS[1], ARGV[i])
+  if redis.call("HGET", KEYS[1], ARGV[i]) == "1" then
+    redis.call("HDEL", KEYS[1], ARGV[i])
+    table.insert(released, ARGV[i])
+  end
 end
 
-return "OK"
+return released
@@ -0,0 +1,51 @@
+-- confirm_hold.lua
+-- Turn a held block of seats into a permanent reservation
+
+-- KEYS[1] = Redis hash key (showtime:{showtimeID}:seats)
+-- KEYS[2] = Redis hash key of active holds (showtime:{showtimeID}:holds)
+-- KEYS[3] = Sorted set of hold expiries (holds:expiry)
+-- KEYS[4] = Hash mapping hold ID to showtime ID (holds:showtimes)
+-- KEYS[5] = Sorted set of the hold expiries of the showtime (showtime:{showtimeID}:holds:expiry)
+-- ARGV[1] = current time in milliseconds
+-- ARGV[2] = hold ID
+-- Returns the list of confirmed seats "row:col"
+
+local now = tonumber(ARGV[1])
+local hold_id = ARGV[2]
+local value = "hold:" .. hold_id
+
+local raw = redis.call("HGET", KEYS[2], hold_id)
+if not raw then
+    return {err="[HOLD_NOT_FOUND] Hold not found: " .. hold_id}
+end
+
+local hold = cjson.decode(raw)
+local expired = hold.expires_at <= now
+
+-- Every seat must still carry this hold, otherwise it was released
+for _, seat in ipairs(hold.seats) do
+    if redis.call("HGET", KEYS[1], seat) ~= value then
+        expired = true
+    end
+end
+
+for _, seat in ipairs(hold.seats) do
+    if redis.call("HGET", KEYS[1], seat) == value then
+        if expired then
+            redis.call("HDEL", KEYS[1], seat)
+        else
+            redis.call("HSET", KEYS[1], seat, "1")
+        end
+    end
+end
+
+redis.call("HDEL", KEYS[2], hold_id)
+redis.call("ZREM", KEYS[3], hold_id)
+redis.call("ZREM", KEYS[5], hold_id)
+redis.call("HDEL", KEYS[4], hold_id)
+
+if expired then
+    return {err="[HOLD_NOT_FOUND] Hold expired: " .. hold_id}
+end
+
+return hold.seats
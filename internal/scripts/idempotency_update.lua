@@ -0,0 +1,21 @@
+-- idempotency_update.lua
+-- Replace the in-progress marker of an idempotency key, only if the key is
+-- still claimed by the same request
+
+-- KEYS[1] = idempotency key (idempotency:{owner}:{method}:{path}:{key})
+-- ARGV[1] = marker the request claimed the key with
+-- ARGV[2] = new value, empty to free the key
+-- ARGV[3] = TTL of the new value in milliseconds
+-- Returns 1 if the key was updated, 0 if it is no longer claimed by the marker
+
+if redis.call("GET", KEYS[1]) ~= ARGV[1] then
+    return 0
+end
+
+if ARGV[2] == "" then
+    redis.call("DEL", KEYS[1])
+else
+    redis.call("SET", KEYS[1], ARGV[2], "PX", ARGV[3])
+end
+
+return 1
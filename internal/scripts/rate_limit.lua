@@ -0,0 +1,49 @@
+-- rate_limit.lua
+-- Take one token from the token bucket of a client. The bucket holds up to
+-- capacity tokens and refills continuously at capacity tokens per window, so
+-- a client may burst up to the limit but never exceed it on average.
+
+-- KEYS[1] = bucket of the client (HASH: tokens, updated_at)
+-- ARGV[1] = capacity, the number of requests per window
+-- ARGV[2] = window in milliseconds
+-- ARGV[3] = current time in milliseconds
+-- Returns {allowed (1 or 0), tokens left, ms until the bucket is full,
+-- ms until the next token when denied}
+
+local capacity = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local rate = capacity / window
+
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updated_at")
+local tokens = tonumber(bucket[1])
+local updated_at = tonumber(bucket[2])
+if tokens == nil or updated_at == nil then
+  -- A missing bucket is a full one
+  tokens = capacity
+  updated_at = now
+end
+
+-- Instances with a clock behind the last update do not refill, and do not
+-- move the bucket back in time
+if now > updated_at then
+  tokens = math.min(capacity, tokens + (now - updated_at) * rate)
+  updated_at = now
+end
+
+local allowed = 0
+local retry_after = 0
+if tokens >= 1 then
+  tokens = tokens - 1
+  allowed = 1
+else
+  retry_after = math.ceil((1 - tokens) / rate)
+end
+
+-- The bucket expires once it would be full again, which is the same as
+-- missing
+local reset = math.ceil((capacity - tokens) / rate)
+redis.call("HSET", KEYS[1], "tokens", tokens, "updated_at", updated_at)
+redis.call("PEXPIRE", KEYS[1], math.max(reset, 1))
+
+return {allowed, math.floor(tokens), reset, retry_after}
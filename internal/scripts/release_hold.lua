@@ -0,0 +1,34 @@
+-- release_hold.lua
+-- Release the seats of a hold and forget about it
+
+-- KEYS[1] = Redis hash key (showtime:{showtimeID}:seats)
+-- KEYS[2] = Redis hash key of active holds (showtime:{showtimeID}:holds)
+-- KEYS[3] = Sorted set of hold expiries (holds:expiry)
+-- KEYS[4] = Hash mapping hold ID to showtime ID (holds:showtimes)
+-- KEYS[5] = Sorted set of the hold expiries of the showtime (showtime:{showtimeID}:holds:expiry)
+-- ARGV[1] = hold ID
+-- Returns the seats "row:col" it freed, nil if the hold did not exist
+
+local hold_id = ARGV[1]
+local value = "hold:" .. hold_id
+
+redis.call("ZREM", KEYS[3], hold_id)
+redis.call("ZREM", KEYS[5], hold_id)
+redis.call("HDEL", KEYS[4], hold_id)
+
+local raw = redis.call("HGET", KEYS[2], hold_id)
+if not raw then
+    return false
+end
+
+local released = {}
+local hold = cjson.decode(raw)
+for _, seat in ipairs(hold.seats) do
+    if redis.call("HGET", KEYS[1], seat) == value then
+        redis.call("HDEL", KEYS[1], seat)
+        table.insert(released, seat)
+    end
+end
+redis.call("HDEL", KEYS[2], hold_id)
+
+return released
@@ -1,43 +1,236 @@
--- KEYS[1] = Redis hash key (cinema:{cinemaID}:seats)
--- ARGV[1] = Manhattan distance
--- ARGV[2..] = seat list: "row:col"
+-- KEYS[1] = Redis hash key (showtime:{showtimeID}:seats)
+-- KEYS[2] = Redis hash key of active holds (showtime:{showtimeID}:holds)
+-- KEYS[3] = Sorted set of hold expiries (holds:expiry)
+-- KEYS[4] = Hash mapping hold ID to showtime ID (holds:showtimes)
+-- KEYS[5] = Sorted set of the hold expiries of the showtime (showtime:{showtimeID}:holds:expiry)
+-- KEYS[6] = Layout of the cinema (cinema:{cinemaID}:layout)
+-- ARGV[1] = minimum distance
+-- ARGV[2] = current time in milliseconds
+-- ARGV[3] = hold ID, empty to reserve the seats permanently
+-- ARGV[4] = hold expiry in milliseconds (ignored without a hold ID)
+-- ARGV[5] = showtime ID (ignored without a hold ID)
+-- ARGV[6] = user ID of the hold owner, 0 for none (ignored without a hold ID)
+-- ARGV[7] = API key ID of the hold owner, 0 for none (ignored without a hold ID)
+-- ARGV[8] = party ID the hold joins, 0 for none (ignored without a hold ID)
+-- ARGV[9] = layout row width, 0 when the cinema has no layout
+-- ARGV[10] = distance policy, see models.DistancePolicy
+-- ARGV[11] = "1" when groups may sit across an aisle
+-- ARGV[12] = number of party seats, exempt from the distance check
+-- ARGV[13..12+n] = party seats: "row:col"
+-- ARGV[13+n..] = seat list: "row:col"
+-- Returns the seats "row:col" of expired holds released to take the seats
 
 local min_dist = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+local hold_id = ARGV[3]
+local expires_at = tonumber(ARGV[4])
+local showtime_id = ARGV[5]
+local owner_user_id = tonumber(ARGV[6])
+local owner_api_key_id = tonumber(ARGV[7])
+local party_id = tonumber(ARGV[8])
+local layout_columns = tonumber(ARGV[9])
+local policy = ARGV[10]
+local span_aisles = ARGV[11] == "1"
+local party_count = tonumber(ARGV[12])
+local party_seats = {}
+local party_list = {}
 local requested_seats = {}
 
-for i = 2, #ARGV do
+for i = 13, 12 + party_count do
+    party_seats[ARGV[i]] = true
+    table.insert(party_list, ARGV[i])
+end
+
+for i = 13 + party_count, #ARGV do
     local coord = ARGV[i]
     table.insert(requested_seats, coord)
 end
 
+-- Same rules as models.DistancePolicy.TooClose
+local function too_close(dr, dc)
+    dr = math.abs(dr)
+    dc = math.abs(dc)
+    if policy == "chebyshev" then
+        return math.max(dr, dc) < min_dist
+    elseif policy == "euclidean" then
+        return dr * dr + dc * dc < min_dist * min_dist
+    elseif policy == "row_only" then
+        return dr == 0 and dc < min_dist
+    elseif policy == "checkerboard" then
+        return (dr == 0 or dc == 0) and dr + dc < min_dist
+    end
+    return dr + dc < min_dist
+end
+
+local function parse_seat(seat)
+    local row, col = seat:match("^(%d+):(%d+)$")
+    return tonumber(row), tonumber(col)
+end
+
+-- Only seats can be reserved, not aisles or gaps
+if layout_columns > 0 then
+    if redis.call("EXISTS", KEYS[6]) == 0 then
+        return {err="[LAYOUT_MISSING] Cinema layout is not loaded"}
+    end
+    for _, seat in ipairs(requested_seats) do
+        local row, col = parse_seat(seat)
+        local offset = row * layout_columns + col
+        local cell = redis.call("GETRANGE", KEYS[6], offset, offset)
+        if cell == "" or cell == "A" or cell == "X" then
+            return {err="[NOT_A_SEAT] No seat at: " .. seat}
+        end
+    end
+end
+
+-- Seats of expired holds of this showtime count as free, so abandoned carts
+-- do not block them. The holds are released once the seats are taken; after
+-- a failed request they are left to the hold sweeper.
+local expired_holds = redis.call("ZRANGEBYSCORE", KEYS[5], "-inf", now)
+local expired_values = {}
+for _, id in ipairs(expired_holds) do
+    expired_values["hold:" .. id] = true
+end
+
+local function is_taken(value)
+    return value and not expired_values[value]
+end
+
 -- Check if any seat is already taken
 for _, seat in ipairs(requested_seats) do
-    if redis.call("HEXISTS", KEYS[1], seat) == 1 then
+    if is_taken(redis.call("HGET", KEYS[1], seat)) then
         return {err="[SEATS_RESERVED] Seat already reserved: " .. seat}
     end
 end
 
--- Check social distancing
-for _, seat1 in ipairs(requested_seats) do
-    local row1, col1 = seat1:match("^(%d+):(%d+)$")
-    row1 = tonumber(row1)
-    col1 = tonumber(col1)
+-- Check social distancing (held seats count as taken, the party's seats do
+-- not). Only the cells close enough to a requested seat are looked up, so
+-- the cost does not grow with how full the hall is.
+local reach = math.max(min_dist - 1, 0)
+local lookup_batch = 1000 -- stay below the Lua stack limit of unpack
+for _, seat in ipairs(requested_seats) do
+    local row, col = parse_seat(seat)
+
+    local near = {}
+    for dr = -reach, reach do
+        for dc = -reach, reach do
+            if (dr ~= 0 or dc ~= 0) and row + dr >= 0 and col + dc >= 0 and too_close(dr, dc) then
+                local other = (row + dr) .. ":" .. (col + dc)
+                if not party_seats[other] then
+                    table.insert(near, other)
+                end
+            end
+        end
+    end
 
-    local keys = redis.call("HKEYS", KEYS[1])
-    for _, seat2 in ipairs(keys) do
-        local row2, col2 = seat2:match("^(%d+):(%d+)$")
-        row2 = tonumber(row2)
-        col2 = tonumber(col2)
-        local dist = math.abs(row1 - row2) + math.abs(col1 - col2)
-        if dist < min_dist then
-            return {err="[MIN_DISTANCE_VIOLATION]Social distancing violated near: " .. seat2}
+    for first = 1, #near, lookup_batch do
+        local last = math.min(first + lookup_batch - 1, #near)
+        local taken = redis.call("HMGET", KEYS[1], unpack(near, first, last))
+        for i = 1, last - first + 1 do
+            if is_taken(taken[i]) then
+                return {err="[MIN_DISTANCE_VIOLATION]Social distancing violated near: " .. near[first + i - 1]}
+            end
         end
     end
 end
 
--- All checks passed, reserve the seats
+-- Requested seats next to each other, or joined through the party's seats,
+-- sit together. Separate groups in one booking keep their distance.
+if #requested_seats > 1 and reach > 0 then
+    local seats = {}
+    for _, seat in ipairs(requested_seats) do
+        table.insert(seats, seat)
+    end
+    for _, seat in ipairs(party_list) do
+        table.insert(seats, seat)
+    end
+
+    local rows, cols, parent = {}, {}, {}
+    for i, seat in ipairs(seats) do
+        rows[i], cols[i] = parse_seat(seat)
+        parent[i] = i
+    end
+
+    local function find(i)
+        while parent[i] ~= i do
+            parent[i] = parent[parent[i]]
+            i = parent[i]
+        end
+        return i
+    end
+
+    -- Seats in the same row are also next to each other across an aisle
+    local function only_aisles_between(row, col1, col2)
+        if not span_aisles or layout_columns == 0 then
+            return false
+        end
+        local offset = row * layout_columns
+        local cells = redis.call("GETRANGE", KEYS[6], offset + col1 + 1, offset + col2 - 1)
+        return cells:match("^A+$") ~= nil
+    end
+
+    local function adjacent(i, j)
+        local dr = math.abs(rows[i] - rows[j])
+        local dc = math.abs(cols[i] - cols[j])
+        if dr + dc <= 1 then -- a seat listed twice counts as next to itself
+            return true
+        end
+        return dr == 0 and dc > 1 and only_aisles_between(rows[i], math.min(cols[i], cols[j]), math.max(cols[i], cols[j]))
+    end
+
+    for i = 1, #seats do
+        for j = i + 1, #seats do
+            if adjacent(i, j) then
+                parent[find(i)] = find(j)
+            end
+        end
+    end
+
+    for i = 1, #requested_seats do
+        for j = i + 1, #requested_seats do
+            if find(i) ~= find(j) and too_close(rows[i] - rows[j], cols[i] - cols[j]) then
+                return {err="[MIN_DISTANCE_VIOLATION]Social distancing violated near: " .. requested_seats[j]}
+            end
+        end
+    end
+end
+
+-- All checks passed, release the expired holds and reserve (or hold) the
+-- seats
+local released = {}
+for _, id in ipairs(expired_holds) do
+    local raw = redis.call("HGET", KEYS[2], id)
+    if raw then
+        for _, seat in ipairs(cjson.decode(raw).seats) do
+            if redis.call("HGET", KEYS[1], seat) == "hold:" .. id then
+                redis.call("HDEL", KEYS[1], seat)
+                table.insert(released, seat)
+            end
+        end
+        redis.call("HDEL", KEYS[2], id)
+    end
+    redis.call("ZREM", KEYS[3], id)
+    redis.call("ZREM", KEYS[5], id)
+    redis.call("HDEL", KEYS[4], id)
+end
+
+local value = "1"
+if hold_id ~= "" then
+    value = "hold:" .. hold_id
+    redis.call("HSET", KEYS[2], hold_id, cjson.encode({
+        expires_at = expires_at,
+        seats = requested_seats,
+        user_id = owner_user_id,
+        api_key_id = owner_api_key_id,
+        party_id = party_id,
+    }))
+    redis.call("ZADD", KEYS[3], expires_at, hold_id)
+    redis.call("ZADD", KEYS[5], expires_at, hold_id)
+    redis.call("HSET", KEYS[4], hold_id, showtime_id)
+end
+
 for _, seat in ipairs(requested_seats) do
-    redis.call("HSET", KEYS[1], seat, "1")
+    redis.call("HSET", KEYS[1], seat, value)
 end
 
-return "OK"
+-- The seats of the expired holds that were released
+return released
@@ -1,8 +1,12 @@
 package scriptloader
 
 import (
+	"context"
 	"embed"
 	"fmt"
+	"path"
+	"sort"
+	"strings"
 
 	"github.com/go-redis/redis/v8"
 )
@@ -10,31 +14,124 @@ import (
 //go:embed *.lua
 var luaFS embed.FS
 
-var (
-	reserveScript *redis.Script
-	cancelScript  *redis.Script
+// Names of the embedded scripts, their file names without the extension.
+const (
+	Reserve     = "reserve"
+	Cancel      = "cancel"
+	ConfirmHold = "confirm_hold"
+	ReleaseHold = "release_hold"
+	SwapSeats   = "swap_seats"
+	RateLimit   = "rate_limit"
+
+	IdempotencyUpdate = "idempotency_update"
 )
 
-func LoadReserveScript() (*redis.Script, error) {
-	if reserveScript != nil {
-		return reserveScript, nil
+// Registry runs the embedded Lua scripts by their SHA. The scripts are read
+// once when the registry is created and never change afterwards, so it is
+// safe for concurrent use.
+type Registry struct {
+	rdb     *redis.Client
+	scripts map[string]*redis.Script
+}
+
+// ScriptStatus is whether a script is in the script cache of Redis.
+type ScriptStatus struct {
+	Name   string `json:"name"`
+	SHA    string `json:"sha"`
+	Loaded bool   `json:"loaded"`
+}
+
+func NewRegistry(rdb *redis.Client) (*Registry, error) {
+	files, err := luaFS.ReadDir(".")
+	if err != nil {
+		return nil, fmt.Errorf("cannot list Lua scripts: %w", err)
 	}
-	data, err := luaFS.ReadFile("reserve.lua")
+
+	scripts := make(map[string]*redis.Script, len(files))
+	for _, file := range files {
+		data, err := luaFS.ReadFile(file.Name())
+		if err != nil {
+			return nil, fmt.Errorf("cannot load Lua script: %w", err)
+		}
+		name := strings.TrimSuffix(file.Name(), path.Ext(file.Name()))
+		scripts[name] = redis.NewScript(string(data))
+	}
+
+	return &Registry{rdb: rdb, scripts: scripts}, nil
+}
+
+// Load puts every script in the script cache of Redis and checks that Redis
+// hashed each one to the SHA it is run by.
+func (r *Registry) Load(ctx context.Context) error {
+	for _, name := range r.names() {
+		err := r.load(ctx, name)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Registry) load(ctx context.Context, name string) error {
+	script := r.scripts[name]
+	sha, err := script.Load(ctx, r.rdb).Result()
 	if err != nil {
-		return nil, fmt.Errorf("cannot load Lua script: %w", err)
+		return fmt.Errorf("cannot load Lua script %s into redis: %w", name, err)
+	}
+	if sha != script.Hash() {
+		return fmt.Errorf("redis loaded Lua script %s as %s, want %s", name, sha, script.Hash())
+	}
+	return nil
+}
+
+// Run runs the script with EVALSHA. When Redis no longer knows the script,
+// e.g. after a restart, it is loaded again and run once more.
+func (r *Registry) Run(ctx context.Context, name string, keys []string, args ...interface{}) *redis.Cmd {
+	script, ok := r.scripts[name]
+	if !ok {
+		cmd := redis.NewCmd(ctx)
+		cmd.SetErr(fmt.Errorf("unknown Lua script %s", name))
+		return cmd
+	}
+
+	cmd := script.EvalSha(ctx, r.rdb, keys, args...)
+	if err := cmd.Err(); err != nil && strings.HasPrefix(err.Error(), "NOSCRIPT") {
+		err = r.load(ctx, name)
+		if err != nil {
+			cmd.SetErr(err)
+			return cmd
+		}
+		cmd = script.EvalSha(ctx, r.rdb, keys, args...)
 	}
-	reserveScript = redis.NewScript(string(data))
-	return reserveScript, nil
+	return cmd
 }
 
-func LoadCancelScript() (*redis.Script, error) {
-	if cancelScript != nil {
-		return cancelScript, nil
+// Status reports which scripts are in the script cache of Redis, sorted by
+// name.
+func (r *Registry) Status(ctx context.Context) ([]ScriptStatus, error) {
+	names := r.names()
+	hashes := make([]string, len(names))
+	for i, name := range names {
+		hashes[i] = r.scripts[name].Hash()
 	}
-	data, err := luaFS.ReadFile("cancel.lua")
+
+	exists, err := r.rdb.ScriptExists(ctx, hashes...).Result()
 	if err != nil {
-		return nil, fmt.Errorf("cannot load Lua script: %w", err)
+		return nil, fmt.Errorf("cannot check Lua scripts in redis: %w", err)
+	}
+
+	status := make([]ScriptStatus, len(names))
+	for i, name := range names {
+		status[i] = ScriptStatus{Name: name, SHA: hashes[i], Loaded: exists[i]}
+	}
+	return status, nil
+}
+
+func (r *Registry) names() []string {
+	names := make([]string, 0, len(r.scripts))
+	for name := range r.scripts {
+		names = append(names, name)
 	}
-	cancelScript = redis.NewScript(string(data))
-	return cancelScript, nil
+	sort.Strings(names)
+	return names
 }
@@ -0,0 +1,69 @@
+package scriptloader
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/go-redis/redis/v8"
+)
+
+func TestNewRegistryReadsEveryScript(t *testing.T) {
+	registry, err := NewRegistry(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, name := range []string{Reserve, Cancel, ConfirmHold, ReleaseHold, SwapSeats, RateLimit, IdempotencyUpdate} {
+		if registry.scripts[name] == nil {
+			t.Errorf("script %s is not registered", name)
+		}
+	}
+}
+
+// TestRegistryReloadsFlushedScripts flushes the script cache of the Redis
+// server in TEST_REDIS_URL, so do not point it at a shared server.
+func TestRegistryReloadsFlushedScripts(t *testing.T) {
+	redisURL := os.Getenv("TEST_REDIS_URL")
+	if redisURL == "" {
+		t.Skip("TEST_REDIS_URL is not set")
+	}
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		t.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	rdb := redis.NewClient(opt)
+	defer rdb.Close()
+
+	ctx := context.Background()
+	registry, err := NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = registry.Load(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = rdb.ScriptFlush(ctx).Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	key := "scriptloader:test:seats"
+	defer rdb.Del(ctx, key)
+	err = registry.Run(ctx, Cancel, []string{key}, "0:0").Err()
+	if err != nil {
+		t.Fatalf("run after flush: %v", err)
+	}
+
+	status, err := registry.Status(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, script := range status {
+		if script.Name == Cancel && !script.Loaded {
+			t.Errorf("script %s was not loaded again", Cancel)
+		}
+	}
+}
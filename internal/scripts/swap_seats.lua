@@ -0,0 +1,24 @@
+-- swap_seats.lua
+-- Atomically replace a seat hash with a freshly rebuilt copy
+
+-- KEYS[1] = rebuilt Redis hash (showtime:{showtimeID}:seats:rebuild:{token})
+-- KEYS[2] = live Redis hash (showtime:{showtimeID}:seats)
+-- Every seat of the live hash is carried over unless the rebuilt copy
+-- already has it: held seats only live in Redis, and seats reserved after the
+-- DB was read may not be in the rebuilt copy yet. Seats reserved in Redis but
+-- not in the DB are left to the reconciler.
+
+local seats = redis.call("HGETALL", KEYS[2])
+for i = 1, #seats, 2 do
+    redis.call("HSETNX", KEYS[1], seats[i], seats[i + 1])
+end
+
+if redis.call("EXISTS", KEYS[1]) == 1 then
+    redis.call("RENAME", KEYS[1], KEYS[2])
+    -- The rebuild key expires in case of a crash, the live key must not
+    redis.call("PERSIST", KEYS[2])
+else
+    redis.call("DEL", KEYS[2])
+end
+
+return "OK"
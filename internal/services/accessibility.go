@@ -0,0 +1,128 @@
+package services
+
+import (
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/utils"
+)
+
+// checkAccessibleSeats enforces the accessibility rules on seats booked
+// together: a companion seat needs a wheelchair space next to it in the same
+// booking, and wheelchair spaces are kept for wheelchair users until the
+// cinema releases them to general sale.
+func checkAccessibleSeats(showtime *models.Showtime, seats []models.Seat, need models.AccessibilityNeed, now time.Time) error {
+	cinema := &showtime.Cinema
+
+	booked := make(map[models.Seat]bool, len(seats))
+	for _, seat := range seats {
+		booked[seat] = true
+	}
+
+	for _, seat := range seats {
+		switch cinema.SeatCategory(seat.Row, seat.Column) {
+		case models.SeatCategoryWheelchair:
+			if need != models.AccessibilityWheelchair && !wheelchairSpacesReleased(showtime, now) {
+				return utils.ErrWheelchairSpaceNotReleased
+			}
+		case models.SeatCategoryCompanion:
+			if !hasBookedWheelchairNeighbor(cinema, booked, seat) {
+				return utils.ErrCompanionWithoutWheelchair
+			}
+		}
+	}
+
+	return nil
+}
+
+// wheelchairSpacesReleased reports whether the wheelchair spaces of the
+// showtime are on general sale.
+func wheelchairSpacesReleased(showtime *models.Showtime, now time.Time) bool {
+	minutes := showtime.Cinema.WheelchairReleaseMinutes
+	if minutes == 0 {
+		return false
+	}
+	releaseAt := showtime.StartsAt.Add(-time.Duration(minutes) * time.Minute)
+	return !now.Before(releaseAt)
+}
+
+// hasBookedWheelchairNeighbor reports whether a wheelchair space right next
+// to the seat, in the same row, is part of the booking.
+func hasBookedWheelchairNeighbor(cinema *models.Cinema, booked map[models.Seat]bool, seat models.Seat) bool {
+	for _, column := range []int{seat.Column - 1, seat.Column + 1} {
+		neighbor := models.Seat{Row: seat.Row, Column: column}
+		if booked[neighbor] && cinema.HasSeat(neighbor.Row, neighbor.Column) &&
+			cinema.SeatCategory(neighbor.Row, neighbor.Column) == models.SeatCategoryWheelchair {
+			return true
+		}
+	}
+	return false
+}
+
+// filterAccessibleBlocks keeps the blocks that can be booked for the need.
+func filterAccessibleBlocks(showtime *models.Showtime, blocks [][]models.Seat, need models.AccessibilityNeed, now time.Time) [][]models.Seat {
+	var filtered [][]models.Seat
+	for _, block := range blocks {
+		if isAccessibleBlock(showtime, block, need, now) {
+			filtered = append(filtered, block)
+		}
+	}
+
+	return filtered
+}
+
+// isAccessibleBlock reports whether the block can be booked for the need.
+// Blocks for wheelchair users must contain a wheelchair space.
+func isAccessibleBlock(showtime *models.Showtime, block []models.Seat, need models.AccessibilityNeed, now time.Time) bool {
+	if checkAccessibleSeats(showtime, block, need, now) != nil {
+		return false
+	}
+	return need != models.AccessibilityWheelchair || hasWheelchairSpace(&showtime.Cinema, block)
+}
+
+func hasWheelchairSpace(cinema *models.Cinema, seats []models.Seat) bool {
+	for _, seat := range seats {
+		if cinema.SeatCategory(seat.Row, seat.Column) == models.SeatCategoryWheelchair {
+			return true
+		}
+	}
+	return false
+}
+
+// accessibleHeat marks the seats no block for the need can contain as unsafe,
+// so the shaped searches skip them instead of rejecting every block with
+// them: wheelchair spaces that are not on sale for the need, and the
+// companion seats that would go with them.
+func accessibleHeat(showtime *models.Showtime, heat [][]bool, need models.AccessibilityNeed, now time.Time) [][]bool {
+	if need == models.AccessibilityWheelchair || wheelchairSpacesReleased(showtime, now) {
+		return heat
+	}
+
+	cinema := &showtime.Cinema
+	narrowed := make([][]bool, len(heat))
+	for r := range heat {
+		narrowed[r] = append([]bool{}, heat[r]...)
+		for c := range narrowed[r] {
+			switch cinema.SeatCategory(r, c) {
+			case models.SeatCategoryWheelchair, models.SeatCategoryCompanion:
+				narrowed[r][c] = true
+			}
+		}
+	}
+	return narrowed
+}
+
+// wheelchairRows lists the rows with a wheelchair space, one of which every
+// block for a wheelchair user has a seat in.
+func wheelchairRows(cinema *models.Cinema) []int {
+	var rows []int
+	for r := 0; r < cinema.Rows; r++ {
+		for c := 0; c < cinema.Columns; c++ {
+			if cinema.HasSeat(r, c) && cinema.SeatCategory(r, c) == models.SeatCategoryWheelchair {
+				rows = append(rows, r)
+				break
+			}
+		}
+	}
+	return rows
+}
@@ -0,0 +1,178 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/utils"
+)
+
+// accessibleShowtime starts at noon in a hall with a wheelchair space between
+// two companion seats, a companion seat far from it and one behind it:
+//
+//	CSWCS
+//	SSCSS
+func accessibleShowtime(releaseMinutes int) *models.Showtime {
+	return &models.Showtime{
+		StartsAt: time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC),
+		Cinema: models.Cinema{
+			Rows:                     2,
+			Columns:                  5,
+			Layout:                   models.Layout{"CSWCS", "SSCSS"},
+			WheelchairReleaseMinutes: releaseMinutes,
+		},
+	}
+}
+
+func TestCheckAccessibleSeats(t *testing.T) {
+	wheelchair := models.Seat{Row: 0, Column: 2}
+	companion := models.Seat{Row: 0, Column: 3}
+	farCompanion := models.Seat{Row: 0, Column: 0}
+	companionBehind := models.Seat{Row: 1, Column: 2}
+	standard := models.Seat{Row: 0, Column: 1}
+
+	tests := []struct {
+		name           string
+		releaseMinutes int
+		seats          []models.Seat
+		need           models.AccessibilityNeed
+		beforeStart    time.Duration
+		want           error
+	}{
+		{"standard seat", 30, []models.Seat{standard}, "", 2 * time.Hour, nil},
+		{"wheelchair space for a wheelchair user", 30, []models.Seat{wheelchair}, models.AccessibilityWheelchair, 2 * time.Hour, nil},
+		{"wheelchair space held back", 30, []models.Seat{wheelchair}, "", 2 * time.Hour, utils.ErrWheelchairSpaceNotReleased},
+		{"wheelchair space just before the release", 30, []models.Seat{wheelchair}, "", 30*time.Minute + time.Millisecond, utils.ErrWheelchairSpaceNotReleased},
+		{"wheelchair space at the release", 30, []models.Seat{wheelchair}, "", 30 * time.Minute, nil},
+		{"wheelchair space just after the release", 30, []models.Seat{wheelchair}, "", 30*time.Minute - time.Millisecond, nil},
+		{"wheelchair space never released", 0, []models.Seat{wheelchair}, "", time.Minute, utils.ErrWheelchairSpaceNotReleased},
+		{"companion with its wheelchair space", 30, []models.Seat{wheelchair, companion}, models.AccessibilityWheelchair, 2 * time.Hour, nil},
+		{"companion alone", 30, []models.Seat{companion}, models.AccessibilityWheelchair, 2 * time.Hour, utils.ErrCompanionWithoutWheelchair},
+		{"companion away from the wheelchair space", 30, []models.Seat{wheelchair, farCompanion}, models.AccessibilityWheelchair, 2 * time.Hour, utils.ErrCompanionWithoutWheelchair},
+		{"companion behind the wheelchair space", 30, []models.Seat{wheelchair, companionBehind}, models.AccessibilityWheelchair, 2 * time.Hour, utils.ErrCompanionWithoutWheelchair},
+		{"companion once released", 30, []models.Seat{wheelchair, companion}, "", 10 * time.Minute, nil},
+		{"companion alone once released", 30, []models.Seat{companion}, "", 10 * time.Minute, utils.ErrCompanionWithoutWheelchair},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			showtime := accessibleShowtime(tt.releaseMinutes)
+			now := showtime.StartsAt.Add(-tt.beforeStart)
+
+			err := checkAccessibleSeats(showtime, tt.seats, tt.need, now)
+			if !errors.Is(err, tt.want) {
+				t.Errorf("checkAccessibleSeats() = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildReservedSeatsEnforcesAccessibility(t *testing.T) {
+	showtime := accessibleShowtime(30)
+	showtime.StartsAt = time.Now().Add(time.Hour)
+
+	_, err := buildReservedSeats(showtime, []models.SeatRequest{{Row: 0, Column: 2}}, "")
+	if !errors.Is(err, utils.ErrWheelchairSpaceNotReleased) {
+		t.Errorf("wheelchair space without the need = %v, want %v", err, utils.ErrWheelchairSpaceNotReleased)
+	}
+	_, err = buildReservedSeats(showtime, []models.SeatRequest{{Row: 0, Column: 3}}, models.AccessibilityWheelchair)
+	if !errors.Is(err, utils.ErrCompanionWithoutWheelchair) {
+		t.Errorf("companion alone = %v, want %v", err, utils.ErrCompanionWithoutWheelchair)
+	}
+	seats, err := buildReservedSeats(showtime, []models.SeatRequest{{Row: 0, Column: 2}, {Row: 0, Column: 3}}, models.AccessibilityWheelchair)
+	if err != nil || len(seats) != 2 {
+		t.Errorf("wheelchair space with its companion = %v, %v", seats, err)
+	}
+}
+
+// TestAccessibleHeat checks which cells the searches of GetAvailableSeats
+// treat as unsafe around the release time.
+func TestAccessibleHeat(t *testing.T) {
+	tests := []struct {
+		need        models.AccessibilityNeed
+		beforeStart time.Duration
+		want        []string // "x" for unsafe cells
+	}{
+		{"", time.Hour, []string{"x.xx.", "..x.."}},
+		{"", 30*time.Minute + time.Millisecond, []string{"x.xx.", "..x.."}},
+		{"", 30 * time.Minute, []string{".....", "....."}},
+		{models.AccessibilityWheelchair, time.Hour, []string{".....", "....."}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%v", tt.need, tt.beforeStart), func(t *testing.T) {
+			showtime := accessibleShowtime(30)
+			heat := [][]bool{make([]bool, 5), make([]bool, 5)}
+
+			narrowed := accessibleHeat(showtime, heat, tt.need, showtime.StartsAt.Add(-tt.beforeStart))
+			for r, row := range tt.want {
+				for c := range row {
+					if narrowed[r][c] != (row[c] == 'x') {
+						t.Errorf("cell %d:%d unsafe = %v, want %v", r, c, narrowed[r][c], row[c] == 'x')
+					}
+				}
+			}
+			if heat[0][2] {
+				t.Error("accessibleHeat changed the heat map it was given")
+			}
+		})
+	}
+}
+
+func TestFilterAccessibleBlocks(t *testing.T) {
+	showtime := accessibleShowtime(30)
+	now := showtime.StartsAt.Add(-time.Hour)
+	blocks := [][]models.Seat{
+		{{Row: 0, Column: 1}, {Row: 0, Column: 2}},                      // standard and wheelchair
+		{{Row: 0, Column: 2}, {Row: 0, Column: 3}},                      // wheelchair and companion
+		{{Row: 1, Column: 0}, {Row: 1, Column: 1}},                      // standard
+		{{Row: 0, Column: 3}, {Row: 0, Column: 4}},                      // companion without wheelchair
+		{{Row: 1, Column: 1}, {Row: 1, Column: 2}, {Row: 1, Column: 3}}, // companion behind
+	}
+
+	tests := []struct {
+		need models.AccessibilityNeed
+		want []int
+	}{
+		{"", []int{2}},
+		{models.AccessibilityWheelchair, []int{0, 1}},
+	}
+	for _, tt := range tests {
+		t.Run(string(tt.need), func(t *testing.T) {
+			got := filterAccessibleBlocks(showtime, blocks, tt.need, now)
+			if len(got) != len(tt.want) {
+				t.Fatalf("got %d blocks %v, want blocks %v", len(got), got, tt.want)
+			}
+			for i, index := range tt.want {
+				if fmt.Sprint(got[i]) != fmt.Sprint(blocks[index]) {
+					t.Errorf("block %d = %v, want %v", i, got[i], blocks[index])
+				}
+			}
+		})
+	}
+}
+
+func TestBuildSeatMapShowsAccessibleSeats(t *testing.T) {
+	showtime := accessibleShowtime(30)
+	seats := &seatSnapshot{
+		occupied: map[string]string{"0:2": "hold:abc"},
+		heat:     [][]bool{make([]bool, 5), make([]bool, 5)},
+	}
+
+	seatMap := buildSeatMap(&showtime.Cinema, showtime, seats)
+	if got := seatMap.Categories[0][2]; got != models.SeatCategoryWheelchair {
+		t.Errorf("category of 0:2 = %q, want %q", got, models.SeatCategoryWheelchair)
+	}
+	if got := seatMap.Categories[1][2]; got != models.SeatCategoryCompanion {
+		t.Errorf("category of 1:2 = %q, want %q", got, models.SeatCategoryCompanion)
+	}
+	if got := seatMap.Seats[0][2]; got != models.SeatHeld {
+		t.Errorf("state of the held wheelchair space = %q, want %q", got, models.SeatHeld)
+	}
+	if got := seatMap.Seats[0][3]; got != models.SeatAvailable {
+		t.Errorf("state of the free companion seat = %q, want %q", got, models.SeatAvailable)
+	}
+}
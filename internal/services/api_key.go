@@ -0,0 +1,187 @@
+package services
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/repositories"
+	"cinema-reservation/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// apiKeyPrefix starts every key so a leaked one is easy to recognize.
+	apiKeyPrefix = "ck_"
+	// apiKeyDisplayLength is how much of a key is kept to tell keys apart.
+	apiKeyDisplayLength = 10
+	// maxUsageDays caps the range of a usage query.
+	maxUsageDays    = 366
+	usageDateFormat = time.DateOnly
+)
+
+type apiKeyService struct {
+	apiKeyRepo repositories.APIKeyRepository
+	cinemaRepo repositories.CinemaRepository
+	redis      *redis.Client
+}
+
+func NewAPIKeyService(apiKeyRepo repositories.APIKeyRepository, cinemaRepo repositories.CinemaRepository, redis *redis.Client) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		cinemaRepo: cinemaRepo,
+		redis:      redis,
+	}
+}
+
+// CreateKey issues a key. Its secret is only returned here.
+func (s *apiKeyService) CreateKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.IssuedAPIKey, error) {
+	cinemaIDs := []uint{}
+	seen := make(map[uint]bool)
+	for _, slug := range req.CinemaSlugs {
+		cinema, err := s.cinemaRepo.GetBySlug(ctx, slug)
+		if err != nil {
+			logrus.WithError(err).Error("failed to get cinema by slug")
+			return nil, utils.ErrInternalServer
+		}
+		if cinema == nil {
+			return nil, utils.ErrCinemaNotFound
+		}
+		if !seen[cinema.ID] {
+			seen[cinema.ID] = true
+			cinemaIDs = append(cinemaIDs, cinema.ID)
+		}
+	}
+
+	token, err := utils.RandomToken(24)
+	if err != nil {
+		logrus.WithError(err).Error("failed to generate api key")
+		return nil, utils.ErrInternalServer
+	}
+	secret := apiKeyPrefix + token
+
+	key := &models.APIKey{
+		Name:      strings.TrimSpace(req.Name),
+		Prefix:    secret[:apiKeyDisplayLength],
+		KeyHash:   hashAPIKey(secret),
+		RateLimit: req.RateLimit,
+		CinemaIDs: cinemaIDs,
+	}
+	err = s.apiKeyRepo.Create(ctx, key)
+	if err != nil {
+		logrus.WithError(err).Error("failed to create api key")
+		return nil, utils.ErrInternalServer
+	}
+
+	return &models.IssuedAPIKey{APIKey: key, Key: secret}, nil
+}
+
+func (s *apiKeyService) ListKeys(ctx context.Context) ([]models.APIKey, error) {
+	keys, err := s.apiKeyRepo.List(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("failed to list api keys")
+		return nil, utils.ErrInternalServer
+	}
+	return keys, nil
+}
+
+func (s *apiKeyService) RevokeKey(ctx context.Context, id uint) error {
+	_, err := s.getKey(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.apiKeyRepo.Revoke(ctx, id, time.Now())
+	if err != nil {
+		logrus.WithError(err).Error("failed to revoke api key")
+		return utils.ErrInternalServer
+	}
+	return nil
+}
+
+// Authenticate returns the key with the secret, unless it was revoked.
+func (s *apiKeyService) Authenticate(ctx context.Context, secret string) (*models.APIKey, error) {
+	if !strings.HasPrefix(secret, apiKeyPrefix) {
+		return nil, utils.ErrInvalidAPIKey
+	}
+
+	key, err := s.apiKeyRepo.GetByHash(ctx, hashAPIKey(secret))
+	if err != nil {
+		logrus.WithError(err).Error("failed to get api key by hash")
+		return nil, utils.ErrInternalServer
+	}
+	if key == nil {
+		return nil, utils.ErrInvalidAPIKey
+	}
+	return key, nil
+}
+
+// RecordUsage counts a request of the key on the current day. A failure is
+// only logged, it must not fail the request.
+func (s *apiKeyService) RecordUsage(ctx context.Context, keyID uint) {
+	day := time.Now().UTC().Format(usageDateFormat)
+	err := s.redis.HIncrBy(ctx, apiKeyUsageKey(keyID), day, 1).Err()
+	if err != nil {
+		logrus.WithError(err).Warnf("failed to record usage of api key %d", keyID)
+	}
+}
+
+// GetUsage returns the requests of the key per day from from to to, both
+// included. Days without requests are listed with zero.
+func (s *apiKeyService) GetUsage(ctx context.Context, id uint, from, to time.Time) ([]models.APIKeyUsage, error) {
+	from = from.UTC().Truncate(24 * time.Hour)
+	to = to.UTC().Truncate(24 * time.Hour)
+	if to.Before(from) || to.Sub(from) >= maxUsageDays*24*time.Hour {
+		return nil, utils.ErrInvalidUsageRange
+	}
+
+	_, err := s.getKey(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var days []string
+	for day := from; !day.After(to); day = day.AddDate(0, 0, 1) {
+		days = append(days, day.Format(usageDateFormat))
+	}
+
+	counts, err := s.redis.HMGet(ctx, apiKeyUsageKey(id), days...).Result()
+	if err != nil {
+		logrus.WithError(err).Error("failed to get api key usage")
+		return nil, utils.ErrInternalServer
+	}
+
+	usage := make([]models.APIKeyUsage, len(days))
+	for i, day := range days {
+		usage[i].Date = day
+		if count, ok := counts[i].(string); ok {
+			fmt.Sscan(count, &usage[i].Requests)
+		}
+	}
+	return usage, nil
+}
+
+func (s *apiKeyService) getKey(ctx context.Context, id uint) (*models.APIKey, error) {
+	key, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get api key by id")
+		return nil, utils.ErrInternalServer
+	}
+	if key == nil {
+		return nil, utils.ErrAPIKeyNotFound
+	}
+	return key, nil
+}
+
+// hashAPIKey is how keys are stored. Keys are long and random, so a plain
+// SHA-256 is enough and keeps the lookup on every request cheap.
+func hashAPIKey(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
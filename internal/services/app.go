@@ -1,73 +1,178 @@
 package services
 
 import (
+	"cinema-reservation/internal/models"
 	"cinema-reservation/internal/repositories"
+	scriptloader "cinema-reservation/internal/scripts"
+	"cinema-reservation/internal/utils"
 	"context"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/sirupsen/logrus"
 )
 
+// rebuildKeyTTL bounds the lifetime of a half-built seat hash left behind by
+// a crash during a resync.
+const rebuildKeyTTL = 5 * time.Minute
+
 type appService struct {
 	reservationRepo repositories.ReservationRepository
+	cinemaRepo      repositories.CinemaRepository
+	showtimeRepo    repositories.ShowtimeRepository
 	redis           *redis.Client
+	scripts         *scriptloader.Registry
+
+	// suspects holds the drift seen by the previous reconcile pass
+	mu       sync.Mutex
+	suspects map[models.SeatFix]struct{}
 }
 
-func NewAppService(reservationRepo repositories.ReservationRepository, redis *redis.Client) AppService {
-	return &appService{reservationRepo: reservationRepo, redis: redis}
+func NewAppService(
+	reservationRepo repositories.ReservationRepository,
+	cinemaRepo repositories.CinemaRepository,
+	showtimeRepo repositories.ShowtimeRepository,
+	redis *redis.Client,
+	scripts *scriptloader.Registry,
+) AppService {
+	return &appService{
+		reservationRepo: reservationRepo,
+		cinemaRepo:      cinemaRepo,
+		showtimeRepo:    showtimeRepo,
+		redis:           redis,
+		scripts:         scripts,
+		suspects:        make(map[models.SeatFix]struct{}),
+	}
 }
 
 func (s *appService) SyncReservationsToRedis() error {
 	ctx := context.Background()
-	logrus.Println("Starting simple sync of reservations to Redis...")
-	startTime := time.Now()
+	logrus.Println("Starting sync of reservations to Redis...")
 
-	// Get all reserved seats
-	reservedSeats, err := s.reservationRepo.GetAllReservedSeats(ctx)
+	report, err := s.resyncAll(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to fetch reserved seats: %w", err)
+		return err
 	}
 
-	logrus.Printf("Found %d reserved seats to sync", len(reservedSeats))
+	logrus.Printf("Successfully synced %d reserved seats across %d showtimes to Redis in %v",
+		report.SeatsSynced, report.ShowtimesRebuilt, report.Duration)
+
+	return nil
+}
 
-	cinemaSeats := make(map[uint][]string)
-	for _, seat := range reservedSeats {
-		seatKey := fmt.Sprintf("%d:%d", seat.Row, seat.Column)
-		cinemaSeats[seat.CinemaID] = append(cinemaSeats[seat.CinemaID], seatKey)
+// ResyncAll rebuilds the seat hash of every showtime known to the DB or Redis.
+func (s *appService) ResyncAll(ctx context.Context) (*models.ResyncReport, error) {
+	report, err := s.resyncAll(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("failed to resync all showtimes")
+		return nil, utils.ErrInternalServer
 	}
+	return report, nil
+}
 
-	pipe := s.redis.Pipeline()
+func (s *appService) resyncAll(ctx context.Context) (*models.ResyncReport, error) {
+	showtimeIDs, err := s.knownShowtimeIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
 
-	keys, err := s.redis.Keys(ctx, "cinema:*:seats").Result()
-	if err == nil && len(keys) > 0 {
-		pipe.Del(ctx, keys...)
+	return s.resyncShowtimes(ctx, showtimeIDs)
+}
+
+// ResyncCinema rebuilds the seat hashes of the showtimes of a single cinema.
+func (s *appService) ResyncCinema(ctx context.Context, slug string) (*models.ResyncReport, error) {
+	cinema, err := s.cinemaRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get cinema by slug")
+		return nil, utils.ErrInternalServer
+	}
+	if cinema == nil {
+		return nil, utils.ErrCinemaNotFound
 	}
 
-	// Set reserved seats for each cinema
-	for cinemaID, seatKeys := range cinemaSeats {
-		key := fmt.Sprintf("cinema:%d:seats", cinemaID)
+	err = storeCinemaLayout(ctx, s.redis, cinema)
+	if err != nil {
+		logrus.WithError(err).WithField("cinema_id", cinema.ID).Error("failed to store cinema layout")
+		return nil, utils.ErrInternalServer
+	}
 
-		args := make([]interface{}, 0, len(seatKeys)*2)
-		for _, seatKey := range seatKeys {
-			args = append(args, seatKey, "1")
-		}
+	showtimes, err := s.showtimeRepo.ListByCinema(ctx, cinema.ID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to list showtimes")
+		return nil, utils.ErrInternalServer
+	}
+
+	var showtimeIDs []uint
+	for _, showtime := range showtimes {
+		showtimeIDs = append(showtimeIDs, showtime.ID)
+	}
 
-		if len(args) > 0 {
-			pipe.HSet(ctx, key, args...)
+	report, err := s.resyncShowtimes(ctx, showtimeIDs)
+	if err != nil {
+		logrus.WithError(err).WithField("cinema_id", cinema.ID).Error("failed to resync cinema")
+		return nil, utils.ErrInternalServer
+	}
+
+	return report, nil
+}
+
+func (s *appService) resyncShowtimes(ctx context.Context, showtimeIDs []uint) (*models.ResyncReport, error) {
+	startTime := time.Now()
+	report := &models.ResyncReport{}
+
+	for _, showtimeID := range showtimeIDs {
+		synced, err := s.rebuildShowtimeSeats(ctx, showtimeID)
+		if err != nil {
+			return nil, err
 		}
+		report.ShowtimesRebuilt++
+		report.SeatsSynced += synced
+	}
+
+	report.Duration = time.Since(startTime)
+	return report, nil
+}
+
+// rebuildShowtimeSeats writes the reserved seats of the showtime from the DB
+// into a scratch hash and swaps it in atomically, so readers never see a
+// partially rebuilt seat map. The swap keeps the seats taken in Redis, as
+// reservations made while the DB was read are not in the rebuilt copy.
+func (s *appService) rebuildShowtimeSeats(ctx context.Context, showtimeID uint) (int, error) {
+	reservedSeats, err := s.reservationRepo.GetReservedSeatsByShowtime(ctx, showtimeID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch reserved seats of showtime %d: %w", showtimeID, err)
+	}
+
+	token, err := utils.RandomToken(8)
+	if err != nil {
+		return 0, fmt.Errorf("failed to generate rebuild key: %w", err)
 	}
+	key := showtimeSeatsKey(showtimeID)
+	rebuildKey := key + ":rebuild:" + token
 
-	// Execute all operations
+	pipe := s.redis.Pipeline()
+	pipe.Del(ctx, rebuildKey)
+	if len(reservedSeats) > 0 {
+		args := make([]interface{}, 0, len(reservedSeats)*2)
+		for _, seat := range reservedSeats {
+			args = append(args, fmt.Sprintf("%d:%d", seat.Row, seat.Column), "1")
+		}
+		pipe.HSet(ctx, rebuildKey, args...)
+		pipe.Expire(ctx, rebuildKey, rebuildKeyTTL)
+	}
 	_, err = pipe.Exec(ctx)
 	if err != nil {
-		return fmt.Errorf("failed to execute Redis pipeline: %w", err)
+		return 0, fmt.Errorf("failed to write rebuilt seats of showtime %d: %w", showtimeID, err)
 	}
 
-	duration := time.Since(startTime)
-	logrus.Printf("Successfully synced %d reserved seats across %d cinemas to Redis in %v",
-		len(reservedSeats), len(cinemaSeats), duration)
+	err = s.scripts.Run(ctx, scriptloader.SwapSeats, []string{rebuildKey, key}).Err()
+	if err != nil {
+		return 0, fmt.Errorf("failed to swap seats of showtime %d: %w", showtimeID, err)
+	}
 
-	return nil
+	publishSeatEvent(ctx, s.redis, showtimeID, models.SeatEventResynced, nil)
+
+	return len(reservedSeats), nil
 }
@@ -0,0 +1,61 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cinema-reservation/internal/models"
+	scriptloader "cinema-reservation/internal/scripts"
+)
+
+// TestResyncKeepsSeatsReservedDuringTheRebuild reserves a seat in Redis after
+// the rebuild read the DB and before it swaps the seat hash.
+func TestResyncKeepsSeatsReservedDuringTheRebuild(t *testing.T) {
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	showtimeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	key := showtimeSeatsKey(showtimeID)
+	t.Cleanup(func() { rdb.Del(ctx, key) })
+
+	err = rdb.HSet(ctx, key, "0:0", "1", "0:3", "hold:abc").Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	repo := &fakeReservationRepository{reserved: []models.ReservedSeat{
+		{ShowtimeID: showtimeID, Row: 0, Column: 0},
+		{ShowtimeID: showtimeID, Row: 1, Column: 1}, // missing in Redis
+	}}
+	repo.afterRead = func() {
+		err := rdb.HSet(ctx, key, "0:5", "1").Err()
+		if err != nil {
+			t.Error(err)
+		}
+	}
+	service := &appService{reservationRepo: repo, redis: rdb, scripts: scripts}
+
+	_, err = service.rebuildShowtimeSeats(ctx, showtimeID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := rdb.HGetAll(ctx, key).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"0:0": "1", "1:1": "1", "0:3": "hold:abc", "0:5": "1"}
+	if len(got) != len(want) {
+		t.Errorf("seats = %v, want %v", got, want)
+	}
+	for seat, value := range want {
+		if got[seat] != value {
+			t.Errorf("seat %s = %q, want %q", seat, got[seat], value)
+		}
+	}
+}
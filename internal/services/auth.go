@@ -0,0 +1,213 @@
+package services
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/repositories"
+	"cinema-reservation/internal/utils"
+
+	"github.com/sirupsen/logrus"
+	"golang.org/x/crypto/bcrypt"
+)
+
+type authService struct {
+	userRepo   repositories.UserRepository
+	cinemaRepo repositories.CinemaRepository
+	secret     []byte
+	tokenTTL   time.Duration
+}
+
+func NewAuthService(userRepo repositories.UserRepository, cinemaRepo repositories.CinemaRepository, secret string, tokenTTL time.Duration) AuthService {
+	return &authService{
+		userRepo:   userRepo,
+		cinemaRepo: cinemaRepo,
+		secret:     []byte(secret),
+		tokenTTL:   tokenTTL,
+	}
+}
+
+// Register creates a customer account. Other roles are given by an admin.
+func (s *authService) Register(ctx context.Context, req *models.RegisterRequest) (*models.AuthToken, error) {
+	email := normalizeEmail(req.Email)
+
+	exists, err := s.userRepo.ExistsByEmail(ctx, email)
+	if err != nil {
+		logrus.WithError(err).Error("failed to check email existence")
+		return nil, utils.ErrInternalServer
+	}
+	if exists {
+		return nil, utils.ErrEmailAlreadyRegistered
+	}
+
+	hash, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
+	if err != nil {
+		logrus.WithError(err).Error("failed to hash password")
+		return nil, utils.ErrInternalServer
+	}
+
+	user := &models.User{
+		Email:        email,
+		Name:         strings.TrimSpace(req.Name),
+		PasswordHash: string(hash),
+		Role:         models.UserRoleCustomer,
+	}
+	err = s.userRepo.Create(ctx, user)
+	if err != nil {
+		logrus.WithError(err).Error("failed to create user")
+		return nil, utils.ErrInternalServer
+	}
+
+	return s.issueToken(user)
+}
+
+func (s *authService) Login(ctx context.Context, req *models.LoginRequest) (*models.AuthToken, error) {
+	user, err := s.userRepo.GetByEmail(ctx, normalizeEmail(req.Email))
+	if err != nil {
+		logrus.WithError(err).Error("failed to get user by email")
+		return nil, utils.ErrInternalServer
+	}
+	if user == nil {
+		return nil, utils.ErrInvalidCredentials
+	}
+
+	err = bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password))
+	if err != nil {
+		return nil, utils.ErrInvalidCredentials
+	}
+
+	return s.issueToken(user)
+}
+
+// Authenticate returns the caller of a token issued by Register or Login.
+// Customers are trusted from the token alone. Any other role is looked up
+// again, so taking a role away applies right away instead of at the next
+// login.
+func (s *authService) Authenticate(ctx context.Context, token string) (*models.Caller, error) {
+	claims, err := utils.ParseToken(s.secret, token, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil || userID == 0 {
+		return nil, utils.ErrInvalidToken
+	}
+
+	caller := &models.Caller{UserID: uint(userID), Role: models.UserRole(claims.Role)}
+	if caller.Role == models.UserRoleCustomer {
+		return caller, nil
+	}
+
+	user, err := s.userRepo.GetByID(ctx, caller.UserID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get user of token")
+		return nil, utils.ErrInternalServer
+	}
+	if user == nil {
+		return nil, utils.ErrInvalidToken
+	}
+	// A promotion still needs a new token
+	if user.Role == models.UserRoleCustomer {
+		caller.Role = models.UserRoleCustomer
+		return caller, nil
+	}
+	caller.Role = user.Role
+
+	if caller.Role == models.UserRoleManager {
+		caller.CinemaIDs, err = s.userRepo.ListManagedCinemaIDs(ctx, caller.UserID)
+		if err != nil {
+			logrus.WithError(err).Error("failed to list managed cinemas")
+			return nil, utils.ErrInternalServer
+		}
+	}
+
+	return caller, nil
+}
+
+// AuthorizeCinema checks that the caller may run the cinema with the slug.
+func (s *authService) AuthorizeCinema(ctx context.Context, caller *models.Caller, slug string) error {
+	if caller.Role != models.UserRoleAdmin && caller.Role != models.UserRoleManager {
+		return utils.ErrInsufficientRole
+	}
+
+	cinema, err := s.cinemaRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get cinema by slug")
+		return utils.ErrInternalServer
+	}
+	if cinema == nil {
+		return utils.ErrCinemaNotFound
+	}
+
+	if !caller.ManagesCinema(cinema.ID) {
+		return utils.ErrCinemaNotManaged
+	}
+	return nil
+}
+
+// SetUserRole gives the user a role. Managers get exactly the cinemas of the
+// request, other roles manage none.
+func (s *authService) SetUserRole(ctx context.Context, userID uint, req *models.SetUserRoleRequest) (*models.User, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get user by id")
+		return nil, utils.ErrInternalServer
+	}
+	if user == nil {
+		return nil, utils.ErrUserNotFound
+	}
+
+	var cinemaIDs []uint
+	if req.Role == models.UserRoleManager {
+		seen := make(map[uint]bool)
+		for _, slug := range req.CinemaSlugs {
+			cinema, err := s.cinemaRepo.GetBySlug(ctx, slug)
+			if err != nil {
+				logrus.WithError(err).Error("failed to get cinema by slug")
+				return nil, utils.ErrInternalServer
+			}
+			if cinema == nil {
+				return nil, utils.ErrCinemaNotFound
+			}
+			if !seen[cinema.ID] {
+				seen[cinema.ID] = true
+				cinemaIDs = append(cinemaIDs, cinema.ID)
+			}
+		}
+	}
+
+	err = s.userRepo.SetRole(ctx, user.ID, req.Role, cinemaIDs)
+	if err != nil {
+		logrus.WithError(err).Error("failed to set user role")
+		return nil, utils.ErrInternalServer
+	}
+
+	user.Role = req.Role
+	return user, nil
+}
+
+func (s *authService) issueToken(user *models.User) (*models.AuthToken, error) {
+	now := time.Now()
+	expiresAt := now.Add(s.tokenTTL)
+
+	token, err := utils.SignToken(s.secret, utils.TokenClaims{
+		Subject:   strconv.FormatUint(uint64(user.ID), 10),
+		Role:      string(user.Role),
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expiresAt.Unix(),
+	})
+	if err != nil {
+		logrus.WithError(err).Error("failed to sign token")
+		return nil, utils.ErrInternalServer
+	}
+
+	return &models.AuthToken{Token: token, ExpiresAt: expiresAt, User: user}, nil
+}
+
+func normalizeEmail(email string) string {
+	return strings.ToLower(strings.TrimSpace(email))
+}
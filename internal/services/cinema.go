@@ -2,9 +2,9 @@ package services
 
 import (
 	"context"
-	"fmt"
 	"strconv"
 	"strings"
+	"time"
 
 	"cinema-reservation/internal/models"
 	"cinema-reservation/internal/repositories"
@@ -16,12 +16,33 @@ import (
 )
 
 type cinemaService struct {
-	cinemaRepo repositories.CinemaRepository
-	redis      *redis.Client
+	cinemaRepo      repositories.CinemaRepository
+	showtimeRepo    repositories.ShowtimeRepository
+	seatPriceRepo   repositories.SeatPriceRepository
+	reservationRepo repositories.ReservationRepository
+	seatEvents      SeatEventHub
+	seats           *seatCache
+	redis           *redis.Client
 }
 
-func NewCinemaService(cinemaRepo repositories.CinemaRepository, redis *redis.Client) CinemaService {
-	return &cinemaService{cinemaRepo: cinemaRepo, redis: redis}
+func NewCinemaService(
+	cinemaRepo repositories.CinemaRepository,
+	showtimeRepo repositories.ShowtimeRepository,
+	seatPriceRepo repositories.SeatPriceRepository,
+	reservationRepo repositories.ReservationRepository,
+	seatEvents SeatEventHub,
+	redis *redis.Client,
+	seatCacheTTL time.Duration,
+) CinemaService {
+	return &cinemaService{
+		cinemaRepo:      cinemaRepo,
+		showtimeRepo:    showtimeRepo,
+		seatPriceRepo:   seatPriceRepo,
+		reservationRepo: reservationRepo,
+		seatEvents:      seatEvents,
+		seats:           newSeatCache(redis, seatEvents, seatCacheTTL),
+		redis:           redis,
+	}
 }
 
 func (s *cinemaService) CreateLayout(ctx context.Context, req *models.CreateCinemaRequest) (*models.Cinema, error) {
@@ -42,11 +63,33 @@ func (s *cinemaService) CreateLayout(ctx context.Context, req *models.CreateCine
 	cinemaSlug := slug.Make(name)
 
 	cinema := &models.Cinema{
-		Name:        name,
-		Slug:        cinemaSlug,
-		Rows:        req.Rows,
-		Columns:     req.Columns,
-		MinDistance: req.MinDistance,
+		Name:             name,
+		Slug:             cinemaSlug,
+		Rows:             req.Rows,
+		Columns:          req.Columns,
+		MinDistance:      req.MinDistance,
+		DistancePolicy:   req.DistancePolicy,
+		BlocksSpanAisles: req.BlocksSpanAisles,
+
+		WheelchairReleaseMinutes: req.WheelchairReleaseMinutes,
+	}
+
+	// An irregular hall takes its size from the layout
+	if len(req.Layout) > 0 {
+		if !req.Layout.Valid() {
+			return nil, utils.ErrInvalidLayout
+		}
+		rows, columns := len(req.Layout), len(req.Layout[0])
+		if (req.Rows != 0 && req.Rows != rows) || (req.Columns != 0 && req.Columns != columns) {
+			return nil, utils.ErrInvalidLayout
+		}
+		cinema.Rows = rows
+		cinema.Columns = columns
+		cinema.Layout = req.Layout
+	}
+
+	if cinema.DistancePolicy == "" {
+		cinema.DistancePolicy = models.DistanceManhattan
 	}
 
 	err = s.cinemaRepo.Create(ctx, cinema)
@@ -55,54 +98,101 @@ func (s *cinemaService) CreateLayout(ctx context.Context, req *models.CreateCine
 		return nil, utils.ErrInternalServer
 	}
 
+	// reserve.lua loads the layout on first use if this fails
+	err = storeCinemaLayout(ctx, s.redis, cinema)
+	if err != nil {
+		logrus.WithError(err).WithField("cinema_id", cinema.ID).Warn("failed to store cinema layout in redis")
+	}
+
 	return cinema, nil
 }
 
-func (s *cinemaService) GetAvailableSeats(ctx context.Context, slug string, groupSize int) ([][]models.Seat, error) {
-	// Get cinema
-	cinema, err := s.cinemaRepo.GetBySlug(ctx, slug)
+// GetAvailableSeats lists the blocks of safe seats in the shape that can be
+// booked for the group and its accessibility need. With a party code of a
+// reservation the caller may access, seats next to the party count as safe.
+// The caller is nil for anonymous requests.
+func (s *cinemaService) GetAvailableSeats(ctx context.Context, caller *models.Caller, slug string, showtimeID uint, query *models.AvailableSeatsQuery) (*models.AvailableSeats, error) {
+	if query.Shape != models.BlockShapeRow && query.GroupSize > maxShapedGroupSize {
+		return nil, utils.ErrGroupTooLargeForShape
+	}
+	if query.Format == models.BlockFormatRuns && query.Shape != models.BlockShapeRow {
+		return nil, utils.ErrRunsNeedRowShape
+	}
+	offset, err := decodeSeatCursor(query.Cursor)
 	if err != nil {
-		logrus.WithError(err).Error("failed to get cinema by slug")
-		return nil, utils.ErrInternalServer
+		return nil, err
 	}
-	if cinema == nil {
-		return nil, utils.ErrCinemaNotFound
+
+	cinema, showtime, err := s.getCinemaShowtime(ctx, slug, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+	// A row outside the hall matches no block, but would still be searched
+	if query.Row != nil && *query.Row >= cinema.Rows {
+		return nil, utils.ErrInvalidRow
 	}
 
-	reserved, err := s.GetRedisReservedSeats(ctx, cinema.ID)
+	seats, err := s.seats.get(ctx, cinema, showtime.ID)
 	if err != nil {
 		logrus.WithError(err).Error("failed to get reserved seats from redis")
 		return nil, utils.ErrInternalServer
 	}
 
-	heatmap := buildHeatmap(cinema.Rows, cinema.Columns, cinema.MinDistance, reserved)
-	available := FindSafeBlocks(heatmap, groupSize)
+	_, partySeats, err := findParty(ctx, s.reservationRepo, caller, showtime, query.PartyCode)
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := seats.heat
+	if len(partySeats) > 0 {
+		heatmap = buildPartyHeatmap(cinema, seats.reserved, seatKeys(partySeats))
+	}
+	now := time.Now()
+
+	// Narrow the search to the seats and rows the filters can accept, the
+	// shaped searches would otherwise try every block of the hall
+	heatmap = accessibleHeat(showtime, heatmap, query.Accessibility, now)
+	var startRows []bool
+	if query.Row != nil {
+		startRows = blockStartRows(startRows, cinema.Rows, query.GroupSize, []int{*query.Row})
+	}
+	if query.Accessibility == models.AccessibilityWheelchair {
+		startRows = blockStartRows(startRows, cinema.Rows, query.GroupSize, wheelchairRows(cinema))
+	}
+
+	blocks := findShapedBlocks(cinema, heatmap, query.GroupSize, query.Shape, startRows, func(block []models.Seat) bool {
+		return (query.Row == nil || blockHasRow(block, *query.Row)) &&
+			isAccessibleBlock(showtime, block, query.Accessibility, now)
+	})
+
+	available := &models.AvailableSeats{}
+	if query.Format == models.BlockFormatRuns {
+		runs := buildSeatRuns(cinema, blocks)
+		available.Runs, available.NextCursor = paginate(runs, offset, query.Limit)
+	} else {
+		available.Blocks, available.NextCursor = paginate(blocks, offset, query.Limit)
+	}
 
 	return available, nil
 }
 
-func (s *cinemaService) CheckAvailableSeats(ctx context.Context, slug string, req *models.CheckSeatsRequest) ([]models.Seat, error) {
-	// Get cinema
-	cinema, err := s.cinemaRepo.GetBySlug(ctx, slug)
+func (s *cinemaService) CheckAvailableSeats(ctx context.Context, slug string, showtimeID uint, req *models.CheckSeatsRequest) ([]models.Seat, error) {
+	cinema, showtime, err := s.getCinemaShowtime(ctx, slug, showtimeID)
 	if err != nil {
-		logrus.WithError(err).Error("failed to get cinema by slug")
-		return nil, utils.ErrInternalServer
-	}
-	if cinema == nil {
-		return nil, utils.ErrCinemaNotFound
+		return nil, err
 	}
 
-	reserved, err := s.GetRedisReservedSeats(ctx, cinema.ID)
+	occupancy, err := s.seats.get(ctx, cinema, showtime.ID)
 	if err != nil {
 		logrus.WithError(err).Error("failed to get reserved seats from redis")
 		return nil, utils.ErrInternalServer
 	}
 
 	seats := req.Seats
-	heatmap := buildHeatmap(cinema.Rows, cinema.Columns, cinema.MinDistance, reserved)
+	heatmap := occupancy.heat
 	var available []models.Seat
 	for _, seat := range seats {
-		if seat.Row < 0 || seat.Row >= cinema.Rows || seat.Column < 0 || seat.Column >= cinema.Columns {
+		if !cinema.HasSeat(seat.Row, seat.Column) {
 			continue
 		}
 
@@ -118,33 +208,274 @@ func (s *cinemaService) CheckAvailableSeats(ctx context.Context, slug string, re
 	return available, nil
 }
 
-func (s *cinemaService) GetRedisReservedSeats(ctx context.Context, cinemaID uint) ([]string, error) {
-	key := fmt.Sprintf("cinema:%d:seats", cinemaID)
+// GetSeatMap returns the state of every cell of the cinema grid for a
+// showtime, so clients can draw a seat picker without re-implementing the
+// distance rule.
+func (s *cinemaService) GetSeatMap(ctx context.Context, slug string, showtimeID uint) (*models.SeatMap, error) {
+	cinema, showtime, err := s.getCinemaShowtime(ctx, slug, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+
+	seats, err := s.seats.get(ctx, cinema, showtime.ID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get reserved seats from redis")
+		return nil, utils.ErrInternalServer
+	}
+	seatMap := buildSeatMap(cinema, showtime, seats)
+
+	seatMap.Prices, err = s.seatPriceRepo.ListByCinema(ctx, cinema.ID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to list seat prices")
+		return nil, utils.ErrInternalServer
+	}
+
+	return seatMap, nil
+}
+
+func (s *cinemaService) GetSeatPrices(ctx context.Context, slug string) ([]models.SeatPrice, error) {
+	cinema, err := s.getCinema(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	prices, err := s.seatPriceRepo.ListByCinema(ctx, cinema.ID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to list seat prices")
+		return nil, utils.ErrInternalServer
+	}
+
+	return prices, nil
+}
+
+// SetSeatPrices replaces the price table of the cinema. Reservations keep the
+// prices they were made at.
+func (s *cinemaService) SetSeatPrices(ctx context.Context, slug string, req *models.SetSeatPricesRequest) ([]models.SeatPrice, error) {
+	cinema, err := s.getCinema(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[models.SeatCategory]bool, len(req.Prices))
+	prices := make([]models.SeatPrice, 0, len(req.Prices))
+	for _, price := range req.Prices {
+		if seen[price.Category] {
+			return nil, utils.ErrDuplicateSeatCategory
+		}
+		seen[price.Category] = true
+		prices = append(prices, models.SeatPrice{Category: price.Category, Amount: price.Amount})
+	}
+
+	err = s.seatPriceRepo.ReplaceForCinema(ctx, cinema.ID, prices)
+	if err != nil {
+		logrus.WithError(err).Error("failed to replace seat prices")
+		return nil, utils.ErrInternalServer
+	}
+
+	return prices, nil
+}
+
+// WatchSeatMap returns the current seat map of the showtime and a channel of
+// the cells that change afterwards. The channel is closed when ctx is done.
+func (s *cinemaService) WatchSeatMap(ctx context.Context, slug string, showtimeID uint) (*models.SeatMap, <-chan *models.SeatMapDelta, error) {
+	cinema, showtime, err := s.getCinemaShowtime(ctx, slug, showtimeID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Subscribe before taking the snapshot so no change slips in between
+	changed, unsubscribe := s.seatEvents.Subscribe(showtime.ID)
+
+	seats, err := s.seats.fresh(ctx, cinema, showtime.ID)
+	if err != nil {
+		unsubscribe()
+		logrus.WithError(err).Error("failed to get reserved seats from redis")
+		return nil, nil, utils.ErrInternalServer
+	}
+	seatMap := buildSeatMap(cinema, showtime, seats)
+
+	seatMap.Prices, err = s.seatPriceRepo.ListByCinema(ctx, cinema.ID)
+	if err != nil {
+		unsubscribe()
+		logrus.WithError(err).Error("failed to list seat prices")
+		return nil, nil, utils.ErrInternalServer
+	}
+
+	deltas := make(chan *models.SeatMapDelta)
+	go func() {
+		defer close(deltas)
+		defer unsubscribe()
+
+		current := seatMap
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-changed:
+			}
+
+			// Read Redis itself, the cache may not have seen the event yet
+			seats, err := s.seats.fresh(ctx, cinema, showtime.ID)
+			if err != nil {
+				logrus.WithError(err).WithField("showtime_id", showtime.ID).Warn("failed to refresh seat map")
+				continue
+			}
+			next := buildSeatMap(cinema, showtime, seats)
+
+			changes := diffSeatMaps(current, next)
+			current = next
+			if len(changes) == 0 {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case deltas <- &models.SeatMapDelta{ShowtimeID: showtime.ID, Seats: changes}:
+			}
+		}
+	}()
+
+	return seatMap, deltas, nil
+}
+
+func buildSeatMap(cinema *models.Cinema, showtime *models.Showtime, seats *seatSnapshot) *models.SeatMap {
+	return &models.SeatMap{
+		ShowtimeID: showtime.ID,
+		Rows:       cinema.Rows,
+		Columns:    cinema.Columns,
+		Seats:      buildSeatStates(cinema, seats),
+		Categories: buildSeatCategories(cinema),
+	}
+}
+
+func (s *cinemaService) getCinema(ctx context.Context, slug string) (*models.Cinema, error) {
+	cinema, err := s.cinemaRepo.GetBySlug(ctx, slug)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get cinema by slug")
+		return nil, utils.ErrInternalServer
+	}
+	if cinema == nil {
+		return nil, utils.ErrCinemaNotFound
+	}
+	return cinema, nil
+}
+
+func (s *cinemaService) getCinemaShowtime(ctx context.Context, slug string, showtimeID uint) (*models.Cinema, *models.Showtime, error) {
+	cinema, err := s.getCinema(ctx, slug)
+	if err != nil {
+		return nil, nil, err
+	}
 
-	data, err := s.redis.HGetAll(ctx, key).Result()
+	showtime, err := findShowtime(ctx, s.showtimeRepo, cinema.ID, showtimeID)
 	if err != nil {
-		return nil, fmt.Errorf("failed to fetch reserved seats from redis hash: %w", err)
+		return nil, nil, err
+	}
+
+	return cinema, showtime, nil
+}
+
+// buildSeatStates classifies every cell of the grid, using the heatmap for
+// the cells that are blocked by the distance rule.
+func buildSeatStates(cinema *models.Cinema, seats *seatSnapshot) [][]models.SeatState {
+	occupied, heat := seats.occupied, seats.heat
+
+	states := make([][]models.SeatState, cinema.Rows)
+	for r := range states {
+		states[r] = make([]models.SeatState, cinema.Columns)
+		for c := range states[r] {
+			switch {
+			case cinema.Layout.Cell(r, c) == models.LayoutAisle:
+				states[r][c] = models.SeatAisle
+			case !cinema.HasSeat(r, c):
+				states[r][c] = models.SeatDisabled
+			case heat[r][c]:
+				states[r][c] = models.SeatBlockedByDistance
+			default:
+				states[r][c] = models.SeatAvailable
+			}
+		}
+	}
+
+	for seat, value := range occupied {
+		r, c, err := parseSeatKey(seat)
+		if err != nil || !cinema.HasSeat(r, c) {
+			continue
+		}
+		if strings.HasPrefix(value, "hold:") {
+			states[r][c] = models.SeatHeld
+		} else {
+			states[r][c] = models.SeatReserved
+		}
 	}
 
-	var reserved []string
-	for seat := range data {
-		reserved = append(reserved, seat)
+	return states
+}
+
+// buildSeatCategories lists the category of every cell of the grid.
+func buildSeatCategories(cinema *models.Cinema) [][]models.SeatCategory {
+	categories := make([][]models.SeatCategory, cinema.Rows)
+	for r := range categories {
+		categories[r] = make([]models.SeatCategory, cinema.Columns)
+		for c := range categories[r] {
+			if cinema.HasSeat(r, c) {
+				categories[r][c] = cinema.SeatCategory(r, c)
+			}
+		}
+	}
+	return categories
+}
+
+// diffSeatMaps lists the cells whose state differs between two seat maps of
+// the same cinema.
+func diffSeatMaps(old, new *models.SeatMap) []models.SeatStateChange {
+	var changes []models.SeatStateChange
+	for r := range new.Seats {
+		for c, state := range new.Seats[r] {
+			if old.Seats[r][c] != state {
+				changes = append(changes, models.SeatStateChange{Row: r, Column: c, State: state})
+			}
+		}
 	}
+	return changes
+}
 
-	return reserved, nil
+// buildHeatmap marks every cell that cannot be sold: cells without a seat,
+// taken seats and seats too close to a taken seat under the distance policy
+// of the cinema. Every policy stays within minDist-1 rows and columns.
+func buildHeatmap(cinema *models.Cinema, reserved []string) [][]bool {
+	return buildPartyHeatmap(cinema, reserved, nil)
 }
 
-func buildHeatmap(rows, cols, minDist int, reserved []string) [][]bool {
+// buildPartyHeatmap is buildHeatmap for a booking joining a party, whose
+// seats are taken but do not block their neighbors.
+func buildPartyHeatmap(cinema *models.Cinema, reserved []string, party []string) [][]bool {
+	rows, cols, minDist := cinema.Rows, cinema.Columns, cinema.MinDistance
+
+	exempt := make(map[string]bool, len(party))
+	for _, seat := range party {
+		exempt[seat] = true
+	}
+
 	heat := make([][]bool, rows)
 	for i := range heat {
 		heat[i] = make([]bool, cols)
+		for j := range heat[i] {
+			heat[i][j] = !cinema.HasSeat(i, j) // no seat
+		}
 	}
 
 	for _, seat := range reserved {
 		parts := strings.Split(seat, ":")
 		r, _ := strconv.Atoi(parts[0])
 		c, _ := strconv.Atoi(parts[1])
+		if r < 0 || r >= rows || c < 0 || c >= cols {
+			continue
+		}
 		heat[r][c] = true // reserved
+		if exempt[seat] {
+			continue
+		}
 
 		rTop := r - minDist + 1
 		if rTop < 0 {
@@ -168,7 +499,7 @@ func buildHeatmap(rows, cols, minDist int, reserved []string) [][]bool {
 				if heat[nr][nc] {
 					continue
 				}
-				if abs(nr-r)+abs(nc-c) < minDist {
+				if cinema.DistancePolicy.TooClose(nr-r, nc-c, minDist) {
 					heat[nr][nc] = true // unsafe
 				}
 			}
@@ -177,25 +508,31 @@ func buildHeatmap(rows, cols, minDist int, reserved []string) [][]bool {
 	return heat
 }
 
-func FindSafeBlocks(heat [][]bool, groupSize int) [][]models.Seat {
+// FindSafeBlocks lists every run of groupSize safe seats in a row. Aisles
+// break a run unless the cinema lets blocks span them.
+func FindSafeBlocks(cinema *models.Cinema, heat [][]bool, groupSize int) [][]models.Seat {
 	rows := len(heat)
 	cols := len(heat[0])
 	var results [][]models.Seat
 
 	for r := 0; r < rows; r++ {
 		for c := 0; c <= cols-groupSize; c++ {
-			valid := true
-			block := []models.Seat{}
+			if heat[r][c] {
+				continue
+			}
 
-			for i := 0; i < groupSize; i++ {
-				if heat[r][c+i] {
-					valid = false
+			block := []models.Seat{}
+			for i := c; i < cols && len(block) < groupSize; i++ {
+				if cinema.BlocksSpanAisles && cinema.Layout.Cell(r, i) == models.LayoutAisle {
+					continue
+				}
+				if heat[r][i] {
 					break
 				}
-				block = append(block, models.Seat{Row: r, Column: c + i})
+				block = append(block, models.Seat{Row: r, Column: i})
 			}
 
-			if valid {
+			if len(block) == groupSize {
 				results = append(results, block)
 			}
 		}
@@ -203,10 +540,3 @@ func FindSafeBlocks(heat [][]bool, groupSize int) [][]models.Seat {
 
 	return results
 }
-
-func abs(n int) int {
-	if n < 0 {
-		return -n
-	}
-	return n
-}
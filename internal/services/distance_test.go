@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"cinema-reservation/internal/models"
+	scriptloader "cinema-reservation/internal/scripts"
+)
+
+var distancePolicies = []models.DistancePolicy{
+	models.DistanceManhattan,
+	models.DistanceChebyshev,
+	models.DistanceEuclidean,
+	models.DistanceRowOnly,
+	models.DistanceCheckerboard,
+}
+
+func TestDistancePolicyTooClose(t *testing.T) {
+	tests := []struct {
+		policy      models.DistancePolicy
+		rowOffset   int
+		colOffset   int
+		minDistance int
+		want        bool
+	}{
+		{models.DistanceManhattan, 0, 1, 2, true},
+		{models.DistanceManhattan, 1, 1, 2, false},
+		{models.DistanceManhattan, -1, 1, 3, true},
+		{models.DistanceManhattan, 0, 3, 3, false},
+		{models.DistanceChebyshev, 1, 1, 2, true},
+		{models.DistanceChebyshev, 2, 1, 2, false},
+		{models.DistanceChebyshev, -2, 2, 3, true},
+		{models.DistanceEuclidean, 1, 1, 2, true},
+		{models.DistanceEuclidean, 2, 0, 2, false},
+		{models.DistanceEuclidean, 2, 2, 3, true},
+		{models.DistanceEuclidean, 3, 0, 3, false},
+		{models.DistanceRowOnly, 0, -1, 2, true},
+		{models.DistanceRowOnly, 1, 0, 2, false},
+		{models.DistanceRowOnly, 0, 2, 3, true},
+		{models.DistanceCheckerboard, 0, 1, 2, true},
+		{models.DistanceCheckerboard, 1, 0, 2, true},
+		{models.DistanceCheckerboard, 1, 1, 2, false},
+		{models.DistanceCheckerboard, -1, 1, 3, false},
+		{models.DistanceCheckerboard, 0, -2, 3, true},
+		{"", 1, 0, 2, true}, // cinemas created before policies were added
+		{models.DistanceManhattan, 0, 1, 0, false},
+	}
+
+	for _, tt := range tests {
+		name := fmt.Sprintf("%s/%d,%d/min%d", tt.policy, tt.rowOffset, tt.colOffset, tt.minDistance)
+		t.Run(name, func(t *testing.T) {
+			got := tt.policy.TooClose(tt.rowOffset, tt.colOffset, tt.minDistance)
+			if got != tt.want {
+				t.Errorf("TooClose() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildHeatmapFollowsPolicy(t *testing.T) {
+	const size, center = 7, 3
+
+	for _, policy := range distancePolicies {
+		for minDistance := 0; minDistance <= 3; minDistance++ {
+			cinema := &models.Cinema{Rows: size, Columns: size, MinDistance: minDistance, DistancePolicy: policy}
+			heat := buildHeatmap(cinema, []string{fmt.Sprintf("%d:%d", center, center)})
+
+			for r := 0; r < size; r++ {
+				for c := 0; c < size; c++ {
+					want := (r == center && c == center) || policy.TooClose(r-center, c-center, minDistance)
+					if heat[r][c] != want {
+						t.Errorf("%s min %d: heat[%d][%d] = %v, want %v", policy, minDistance, r, c, heat[r][c], want)
+					}
+				}
+			}
+		}
+	}
+}
+
+// TestReserveScriptMatchesHeatmap reserves a seat with reserve.lua and then
+// tries every other seat of the grid, expecting the script to reject exactly
+// the seats buildHeatmap marks. It needs a Redis server in TEST_REDIS_URL.
+func TestReserveScriptMatchesHeatmap(t *testing.T) {
+	rdb := testRedis(t)
+
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	const size, center = 7, 3
+	ctx := context.Background()
+	showtimeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	keys := append(holdScriptKeys(showtimeID), cinemaLayoutKey(0))
+	seatsKey := showtimeSeatsKey(showtimeID)
+	defer rdb.Del(ctx, seatsKey)
+
+	reserve := func(cinema *models.Cinema, seat string) error {
+		args := []interface{}{cinema.MinDistance, time.Now().UnixMilli(), "", 0, showtimeID, 0, 0, 0, 0, string(cinema.DistancePolicy), "0", 0, seat}
+		return scripts.Run(ctx, scriptloader.Reserve, keys, args...).Err()
+	}
+
+	for _, policy := range distancePolicies {
+		for minDistance := 0; minDistance <= 3; minDistance++ {
+			cinema := &models.Cinema{Rows: size, Columns: size, MinDistance: minDistance, DistancePolicy: policy}
+			taken := fmt.Sprintf("%d:%d", center, center)
+			heat := buildHeatmap(cinema, []string{taken})
+
+			for r := 0; r < size; r++ {
+				for c := 0; c < size; c++ {
+					if r == center && c == center {
+						continue
+					}
+					rdb.Del(ctx, seatsKey)
+					if err := reserve(cinema, taken); err != nil {
+						t.Fatalf("reserve %s: %v", taken, err)
+					}
+
+					err := reserve(cinema, fmt.Sprintf("%d:%d", r, c))
+					rejected := err != nil && strings.HasPrefix(err.Error(), "[MIN_DISTANCE_VIOLATION]")
+					if err != nil && !rejected {
+						t.Fatalf("reserve %d:%d: %v", r, c, err)
+					}
+					if rejected != heat[r][c] {
+						t.Errorf("%s min %d: seat %d:%d rejected by script = %v, blocked in heatmap = %v",
+							policy, minDistance, r, c, rejected, heat[r][c])
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestBuildPartyHeatmapExemptsParty(t *testing.T) {
+	cinema := &models.Cinema{Rows: 1, Columns: 7, MinDistance: 2, DistancePolicy: models.DistanceManhattan}
+
+	// The party sits at 0:1, someone else at 0:5
+	heat := buildPartyHeatmap(cinema, []string{"0:1", "0:5"}, []string{"0:1"})
+
+	want := []bool{false, true, false, false, true, true, true}
+	for c, blocked := range want {
+		if heat[0][c] != blocked {
+			t.Errorf("heat[0][%d] = %v, want %v", c, heat[0][c], blocked)
+		}
+	}
+}
@@ -0,0 +1,99 @@
+package services
+
+import (
+	"context"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/repositories"
+)
+
+// Fake repositories for the service tests. Methods a test does not use are
+// left to the embedded nil interface.
+
+type fakeCinemaRepository struct {
+	repositories.CinemaRepository
+	cinemas map[string]*models.Cinema
+}
+
+func (r *fakeCinemaRepository) GetBySlug(ctx context.Context, slug string) (*models.Cinema, error) {
+	return r.cinemas[slug], nil
+}
+
+type fakeShowtimeRepository struct {
+	repositories.ShowtimeRepository
+	showtimes map[uint]*models.Showtime
+}
+
+func (r *fakeShowtimeRepository) GetByID(ctx context.Context, id uint) (*models.Showtime, error) {
+	return r.showtimes[id], nil
+}
+
+type fakeSeatPriceRepository struct {
+	repositories.SeatPriceRepository
+}
+
+func (r *fakeSeatPriceRepository) ListByCinema(ctx context.Context, cinemaID uint) ([]models.SeatPrice, error) {
+	return nil, nil
+}
+
+type fakeReservationRepository struct {
+	repositories.ReservationRepository
+	reservations []models.Reservation
+	reserved     []models.ReservedSeat
+	collisions   int      // booking codes reported as taken before one is free
+	checkedCodes []string // booking codes asked for by ExistsByCode
+	afterRead    func()   // runs after GetReservedSeatsByShowtime read the seats
+}
+
+func (r *fakeReservationRepository) GetReservedSeatsByShowtime(ctx context.Context, showtimeID uint) ([]models.ReservedSeat, error) {
+	var seats []models.ReservedSeat
+	for _, seat := range r.reserved {
+		if seat.ShowtimeID == showtimeID {
+			seats = append(seats, seat)
+		}
+	}
+	if r.afterRead != nil {
+		r.afterRead()
+	}
+	return seats, nil
+}
+
+func (r *fakeReservationRepository) ExistsByCode(ctx context.Context, code string) (bool, error) {
+	r.checkedCodes = append(r.checkedCodes, code)
+	if r.collisions > 0 {
+		r.collisions--
+		return true, nil
+	}
+	return false, nil
+}
+
+func (r *fakeReservationRepository) GetByCode(ctx context.Context, code string) (*models.Reservation, error) {
+	for i := range r.reservations {
+		if r.reservations[i].Code == code {
+			return &r.reservations[i], nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeReservationRepository) GetPartySeats(ctx context.Context, partyID uint) ([]models.ReservedSeat, error) {
+	var seats []models.ReservedSeat
+	for _, seat := range r.reserved {
+		if seat.ReservationID == partyID {
+			seats = append(seats, seat)
+		}
+	}
+	return seats, nil
+}
+
+func (r *fakeReservationRepository) FindReservedSeats(ctx context.Context, showtimeID uint, seats []models.Seat) ([]models.ReservedSeat, error) {
+	var found []models.ReservedSeat
+	for _, reserved := range r.reserved {
+		for _, seat := range seats {
+			if reserved.ShowtimeID == showtimeID && reserved.Row == seat.Row && reserved.Column == seat.Column {
+				found = append(found, reserved)
+			}
+		}
+	}
+	return found, nil
+}
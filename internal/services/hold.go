@@ -0,0 +1,321 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cinema-reservation/internal/models"
+	scriptloader "cinema-reservation/internal/scripts"
+	"cinema-reservation/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// expiredHoldBatch caps how many expired holds one sweep releases.
+const expiredHoldBatch = 100
+
+func (s *reservationService) HoldSeats(ctx context.Context, caller *models.Caller, req *models.HoldRequest) (*models.Hold, error) {
+	showtime, err := s.getShowtime(ctx, req.ShowtimeID)
+	if err != nil {
+		return nil, err
+	}
+	if !showtime.StartsAt.After(time.Now()) {
+		return nil, utils.ErrShowtimeAlreadyStarted
+	}
+	if !caller.CanBook(showtime.CinemaID) {
+		return nil, utils.ErrCinemaNotInKeyScope
+	}
+
+	reservedSeats, err := buildReservedSeats(showtime, req.Seats, req.Accessibility)
+	if err != nil {
+		return nil, err
+	}
+
+	partyID, partySeats, err := findParty(ctx, s.reservationRepo, caller, showtime, req.PartyCode)
+	if err != nil {
+		return nil, err
+	}
+
+	holdID, err := utils.RandomToken(16)
+	if err != nil {
+		logrus.WithError(err).Error("failed to generate hold id")
+		return nil, utils.ErrInternalServer
+	}
+	expiresAt := time.Now().Add(s.holdTTL)
+
+	err = s.reserveSeatsRedis(ctx, caller, showtime, reservedSeats, partyID, partySeats, holdID, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+
+	hold := &models.Hold{
+		ID:         holdID,
+		ShowtimeID: showtime.ID,
+		ExpiresAt:  expiresAt,
+		PartyID:    partyID,
+	}
+	for _, seat := range reservedSeats {
+		hold.Seats = append(hold.Seats, models.Seat{Row: seat.Row, Column: seat.Column})
+	}
+
+	return hold, nil
+}
+
+func (s *reservationService) ConfirmHold(ctx context.Context, caller *models.Caller, holdID string, req *models.ConfirmHoldRequest) (*models.Reservation, error) {
+	showtimeID, err := s.holdShowtimeID(ctx, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	showtime, err := s.getShowtime(ctx, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+	if !showtime.StartsAt.After(time.Now()) {
+		return nil, utils.ErrShowtimeAlreadyStarted
+	}
+	if !caller.CanBook(showtime.CinemaID) {
+		return nil, utils.ErrCinemaNotInKeyScope
+	}
+	hold, err := s.getOwnHold(ctx, caller, showtime.ID, showtime.CinemaID, holdID)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.scripts.Run(ctx, scriptloader.ConfirmHold, holdScriptKeys(showtime.ID), time.Now().UnixMilli(), holdID).StringSlice()
+	if err != nil {
+		if strings.HasPrefix(err.Error(), "[HOLD_NOT_FOUND]") {
+			return nil, utils.ErrHoldNotFound
+		}
+		logrus.WithError(err).Error("confirm hold failed")
+		return nil, utils.ErrInternalServer
+	}
+
+	var reservedSeats []models.ReservedSeat
+	for _, seatKey := range result {
+		row, column, err := parseSeatKey(seatKey)
+		if err != nil {
+			logrus.WithError(err).Errorf("unexpected seat in hold: %s", seatKey)
+			return nil, utils.ErrInternalServer
+		}
+		reservedSeats = append(reservedSeats, models.ReservedSeat{
+			CinemaID:   showtime.CinemaID,
+			ShowtimeID: showtime.ID,
+			Row:        row,
+			Column:     column,
+		})
+	}
+
+	var partyID *uint
+	if hold.PartyID != 0 {
+		partyID = &hold.PartyID
+	}
+
+	reservation, err := s.createReservation(ctx, caller, showtime, reservedSeats, partyID, req.Note)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only announced once stored, a failed insert releases the seats instead
+	publishSeatEvent(ctx, s.redis, showtime.ID, models.SeatEventConfirmed, models.ReservedSeats(reservedSeats).Seats())
+
+	return reservation, nil
+}
+
+func (s *reservationService) ReleaseHold(ctx context.Context, caller *models.Caller, holdID string) error {
+	showtimeID, err := s.holdShowtimeID(ctx, holdID)
+	if err != nil {
+		return err
+	}
+
+	showtime, err := s.getShowtime(ctx, showtimeID)
+	if err != nil {
+		return err
+	}
+	_, err = s.getOwnHold(ctx, caller, showtime.ID, showtime.CinemaID, holdID)
+	if err != nil {
+		return err
+	}
+
+	released, err := s.releaseHoldRedis(ctx, showtimeID, holdID)
+	if err != nil {
+		logrus.WithError(err).Error("release hold failed")
+		return utils.ErrInternalServer
+	}
+	if !released {
+		return utils.ErrHoldNotFound
+	}
+
+	return nil
+}
+
+// ReleaseExpiredHolds frees the seats of every hold past its expiry and
+// returns how many holds were released.
+func (s *reservationService) ReleaseExpiredHolds(ctx context.Context) (int, error) {
+	holdIDs, err := s.redis.ZRangeByScore(ctx, holdExpiryKey, &redis.ZRangeBy{
+		Min:   "-inf",
+		Max:   strconv.FormatInt(time.Now().UnixMilli(), 10),
+		Count: expiredHoldBatch,
+	}).Result()
+	if err != nil {
+		return 0, fmt.Errorf("failed to fetch expired holds: %w", err)
+	}
+
+	released := 0
+	for _, holdID := range holdIDs {
+		showtimeID, err := s.redis.HGet(ctx, holdShowtimesKey, holdID).Uint64()
+		if err == redis.Nil {
+			// Already confirmed or released, only the expiry entry is left
+			s.redis.ZRem(ctx, holdExpiryKey, holdID)
+			continue
+		}
+		if err != nil {
+			return released, fmt.Errorf("failed to fetch showtime of hold: %w", err)
+		}
+
+		ok, err := s.releaseHoldRedis(ctx, uint(showtimeID), holdID)
+		if err != nil {
+			return released, err
+		}
+		if ok {
+			released++
+		}
+	}
+
+	return released, nil
+}
+
+// StartHoldSweeper releases expired holds every interval until ctx is done.
+func (s *reservationService) StartHoldSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			released, err := s.ReleaseExpiredHolds(ctx)
+			if err != nil {
+				logrus.WithError(err).Error("failed to release expired holds")
+				continue
+			}
+			if released > 0 {
+				logrus.Infof("Released %d expired holds", released)
+			}
+		}
+	}
+}
+
+func (s *reservationService) holdShowtimeID(ctx context.Context, holdID string) (uint, error) {
+	showtimeID, err := s.redis.HGet(ctx, holdShowtimesKey, holdID).Uint64()
+	if err == redis.Nil {
+		return 0, utils.ErrHoldNotFound
+	}
+	if err != nil {
+		logrus.WithError(err).Error("failed to get showtime of hold")
+		return 0, utils.ErrInternalServer
+	}
+	return uint(showtimeID), nil
+}
+
+// holdRecord is a hold as reserve.lua stores it in the holds hash of its
+// showtime.
+type holdRecord struct {
+	ExpiresAt int64    `json:"expires_at"`
+	Seats     []string `json:"seats"`
+	UserID    uint     `json:"user_id"`
+	APIKeyID  uint     `json:"api_key_id"`
+	PartyID   uint     `json:"party_id"`
+}
+
+// getOwnHold returns the hold if the caller may confirm or release it, by
+// the rules of the reservation it turns into. The holds of others are
+// reported as not found.
+func (s *reservationService) getOwnHold(ctx context.Context, caller *models.Caller, showtimeID, cinemaID uint, holdID string) (*holdRecord, error) {
+	raw, err := s.redis.HGet(ctx, showtimeHoldsKey(showtimeID), holdID).Result()
+	if err == redis.Nil {
+		return nil, utils.ErrHoldNotFound
+	}
+	if err != nil {
+		logrus.WithError(err).Error("failed to get hold")
+		return nil, utils.ErrInternalServer
+	}
+
+	var hold holdRecord
+	err = json.Unmarshal([]byte(raw), &hold)
+	if err != nil {
+		logrus.WithError(err).Errorf("malformed hold %s", holdID)
+		return nil, utils.ErrInternalServer
+	}
+
+	owner := &models.Reservation{CinemaID: cinemaID}
+	if hold.UserID != 0 {
+		owner.UserID = &hold.UserID
+	}
+	if hold.APIKeyID != 0 {
+		owner.APIKeyID = &hold.APIKeyID
+	}
+	if !caller.CanAccess(owner) {
+		return nil, utils.ErrHoldNotFound
+	}
+	return &hold, nil
+}
+
+// releaseHoldRedis frees the seats of the hold and reports whether the hold
+// existed.
+func (s *reservationService) releaseHoldRedis(ctx context.Context, showtimeID uint, holdID string) (bool, error) {
+	result, err := s.scripts.Run(ctx, scriptloader.ReleaseHold, holdScriptKeys(showtimeID), holdID).StringSlice()
+	if err == redis.Nil {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("release hold failed: %w", err)
+	}
+
+	var released []models.Seat
+	for _, seatKey := range result {
+		row, column, err := parseSeatKey(seatKey)
+		if err != nil {
+			logrus.WithError(err).Errorf("unexpected seat in hold: %s", seatKey)
+			continue
+		}
+		released = append(released, models.Seat{Row: row, Column: column})
+	}
+	if len(released) > 0 {
+		publishSeatEvent(ctx, s.redis, showtimeID, models.SeatEventReleased, released)
+	}
+
+	return true, nil
+}
+
+// parseSeatKey splits a "row:col" Redis field into its coordinates.
+func parseSeatKey(seatKey string) (int, int, error) {
+	parts := strings.Split(seatKey, ":")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("malformed seat key %q", seatKey)
+	}
+	row, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	column, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, err
+	}
+	return row, column, nil
+}
+
+// seatKeys formats seats as "row:col" Redis fields.
+func seatKeys(seats []models.Seat) []string {
+	keys := make([]string, 0, len(seats))
+	for _, seat := range seats {
+		keys = append(keys, fmt.Sprintf("%d:%d", seat.Row, seat.Column))
+	}
+	return keys
+}
@@ -0,0 +1,272 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"cinema-reservation/internal/models"
+	scriptloader "cinema-reservation/internal/scripts"
+	"cinema-reservation/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// cleanupShowtimeKeys deletes the seats and holds of the showtime after the
+// test.
+func cleanupShowtimeKeys(t *testing.T, rdb *redis.Client, showtimeID uint) {
+	keys := holdScriptKeys(showtimeID)
+	t.Cleanup(func() {
+		rdb.Del(context.Background(), keys[0], keys[1], showtimeHoldExpiryKey(showtimeID))
+	})
+}
+
+// TestHoldsBelongToTheirCreator holds seats as one customer and checks that
+// another one can neither confirm nor release them.
+func TestHoldsBelongToTheirCreator(t *testing.T) {
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	showtimeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	showtime := &models.Showtime{
+		ID:       showtimeID,
+		CinemaID: 1,
+		StartsAt: time.Now().Add(time.Hour),
+		Cinema:   models.Cinema{ID: 1, Rows: 2, Columns: 4, MinDistance: 1},
+	}
+	cleanupShowtimeKeys(t, rdb, showtimeID)
+
+	service := &reservationService{
+		showtimeRepo: &fakeShowtimeRepository{showtimes: map[uint]*models.Showtime{showtimeID: showtime}},
+		redis:        rdb,
+		scripts:      scripts,
+		holdTTL:      time.Minute,
+	}
+	owner := &models.Caller{UserID: 1, Role: models.UserRoleCustomer}
+	other := &models.Caller{UserID: 2, Role: models.UserRoleCustomer}
+
+	hold, err := service.HoldSeats(ctx, owner, &models.HoldRequest{
+		ShowtimeID: showtimeID,
+		Seats:      []models.SeatRequest{{Row: 0, Column: 0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = service.ConfirmHold(ctx, other, hold.ID, &models.ConfirmHoldRequest{})
+	if !errors.Is(err, utils.ErrHoldNotFound) {
+		t.Errorf("confirm by another customer = %v, want %v", err, utils.ErrHoldNotFound)
+	}
+	err = service.ReleaseHold(ctx, other, hold.ID)
+	if !errors.Is(err, utils.ErrHoldNotFound) {
+		t.Errorf("release by another customer = %v, want %v", err, utils.ErrHoldNotFound)
+	}
+
+	err = service.ReleaseHold(ctx, owner, hold.ID)
+	if err != nil {
+		t.Errorf("release by the owner = %v", err)
+	}
+}
+
+// TestHoldsCannotBeConfirmedOnceTheShowtimeStarted holds seats and tries to
+// confirm them after the showtime started.
+func TestHoldsCannotBeConfirmedOnceTheShowtimeStarted(t *testing.T) {
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	showtimeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	showtime := &models.Showtime{
+		ID:       showtimeID,
+		CinemaID: 1,
+		StartsAt: time.Now().Add(time.Hour),
+		Cinema:   models.Cinema{ID: 1, Rows: 2, Columns: 4, MinDistance: 1},
+	}
+	cleanupShowtimeKeys(t, rdb, showtimeID)
+
+	service := &reservationService{
+		showtimeRepo: &fakeShowtimeRepository{showtimes: map[uint]*models.Showtime{showtimeID: showtime}},
+		redis:        rdb,
+		scripts:      scripts,
+		holdTTL:      time.Hour,
+	}
+	caller := &models.Caller{UserID: 1, Role: models.UserRoleCustomer}
+
+	hold, err := service.HoldSeats(ctx, caller, &models.HoldRequest{
+		ShowtimeID: showtimeID,
+		Seats:      []models.SeatRequest{{Row: 0, Column: 0}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { service.ReleaseHold(ctx, caller, hold.ID) })
+
+	showtime.StartsAt = time.Now().Add(-time.Minute)
+	_, err = service.ConfirmHold(ctx, caller, hold.ID, &models.ConfirmHoldRequest{})
+	if !errors.Is(err, utils.ErrShowtimeAlreadyStarted) {
+		t.Errorf("confirm after the start = %v, want %v", err, utils.ErrShowtimeAlreadyStarted)
+	}
+}
+
+// TestReserveReleasesExpiredHoldsOfItsShowtime reserves a seat whose hold
+// expired and checks that the expired hold of another showtime is left to
+// the sweeper.
+func TestReserveReleasesExpiredHoldsOfItsShowtime(t *testing.T) {
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	showtimeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	otherID := showtimeID + 1
+	showtimes := map[uint]*models.Showtime{}
+	for _, id := range []uint{showtimeID, otherID} {
+		showtimes[id] = &models.Showtime{
+			ID:       id,
+			CinemaID: 1,
+			StartsAt: time.Now().Add(time.Hour),
+			Cinema:   models.Cinema{ID: 1, Rows: 1, Columns: 4, MinDistance: 1},
+		}
+		cleanupShowtimeKeys(t, rdb, id)
+	}
+
+	// Holds that expire as soon as they are made
+	service := &reservationService{
+		showtimeRepo: &fakeShowtimeRepository{showtimes: showtimes},
+		redis:        rdb,
+		scripts:      scripts,
+		holdTTL:      -time.Minute,
+	}
+	caller := &models.Caller{UserID: 1, Role: models.UserRoleCustomer}
+	var holds []*models.Hold
+	for _, id := range []uint{showtimeID, otherID} {
+		hold, err := service.HoldSeats(ctx, caller, &models.HoldRequest{
+			ShowtimeID: id,
+			Seats:      []models.SeatRequest{{Row: 0, Column: 0}},
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		holds = append(holds, hold)
+	}
+	t.Cleanup(func() {
+		for _, hold := range holds {
+			rdb.ZRem(ctx, holdExpiryKey, hold.ID)
+			rdb.HDel(ctx, holdShowtimesKey, hold.ID)
+		}
+	})
+
+	keys := append(holdScriptKeys(showtimeID), cinemaLayoutKey(1))
+	reserve := func(seats ...interface{}) ([]string, error) {
+		args := append([]interface{}{1, time.Now().UnixMilli(), "", 0, showtimeID, 0, 0, 0, 0, "manhattan", "0", 0}, seats...)
+		return scripts.Run(ctx, scriptloader.Reserve, keys, args...).StringSlice()
+	}
+
+	// A failed reservation leaves the expired hold to the sweeper
+	err = rdb.HSet(ctx, keys[0], "0:3", "1").Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = reserve("0:0", "0:3")
+	if err == nil || !strings.HasPrefix(err.Error(), "[SEATS_RESERVED]") {
+		t.Fatalf("reserving a sold seat = %v, want SEATS_RESERVED", err)
+	}
+	if got := rdb.HGet(ctx, keys[0], "0:0").Val(); got != "hold:"+holds[0].ID {
+		t.Errorf("seat of the expired hold after a failed reservation = %q, want it held", got)
+	}
+
+	released, err := reserve("0:0")
+	if err != nil {
+		t.Fatalf("reserving the seat of an expired hold: %v", err)
+	}
+	if len(released) != 1 || released[0] != "0:0" {
+		t.Errorf("released seats = %v, want [0:0]", released)
+	}
+	if rdb.HExists(ctx, holdShowtimesKey, holds[0].ID).Val() {
+		t.Error("the expired hold of the showtime was not forgotten")
+	}
+	if rdb.ZScore(ctx, showtimeHoldExpiryKey(showtimeID), holds[0].ID).Err() == nil {
+		t.Error("the expired hold is still in the expiries of the showtime")
+	}
+
+	if !rdb.HExists(ctx, holdShowtimesKey, holds[1].ID).Val() {
+		t.Error("the expired hold of another showtime was released")
+	}
+	if got := rdb.HGet(ctx, showtimeSeatsKey(otherID), "0:0").Val(); got != "hold:"+holds[1].ID {
+		t.Errorf("seat of the other showtime = %q, want it held", got)
+	}
+}
+
+// TestReleaseHoldAnnouncesItsSeats releases a hold while listening to the
+// seat events of the showtime.
+func TestReleaseHoldAnnouncesItsSeats(t *testing.T) {
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	showtimeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	showtime := &models.Showtime{
+		ID:       showtimeID,
+		CinemaID: 1,
+		StartsAt: time.Now().Add(time.Hour),
+		Cinema:   models.Cinema{ID: 1, Rows: 2, Columns: 4, MinDistance: 1},
+	}
+	cleanupShowtimeKeys(t, rdb, showtimeID)
+
+	service := &reservationService{
+		showtimeRepo: &fakeShowtimeRepository{showtimes: map[uint]*models.Showtime{showtimeID: showtime}},
+		redis:        rdb,
+		scripts:      scripts,
+		holdTTL:      time.Minute,
+	}
+	caller := &models.Caller{UserID: 1, Role: models.UserRoleCustomer}
+
+	hold, err := service.HoldSeats(ctx, caller, &models.HoldRequest{
+		ShowtimeID: showtimeID,
+		Seats:      []models.SeatRequest{{Row: 0, Column: 0}, {Row: 0, Column: 1}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	sub := rdb.Subscribe(ctx, showtimeSeatEventsChannel(showtimeID))
+	defer sub.Close()
+	_, err = sub.Receive(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = service.ReleaseHold(ctx, caller, hold.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case msg := <-sub.Channel():
+		var event models.SeatEvent
+		err = json.Unmarshal([]byte(msg.Payload), &event)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if event.Type != models.SeatEventReleased || len(event.Seats) != 2 {
+			t.Errorf("event = %+v, want the two held seats released", event)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no seat event after releasing the hold")
+	}
+}
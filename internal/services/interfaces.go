@@ -2,21 +2,85 @@ package services
 
 import (
 	"context"
+	"time"
 
 	"cinema-reservation/internal/models"
 )
 
 type CinemaService interface {
 	CreateLayout(ctx context.Context, req *models.CreateCinemaRequest) (*models.Cinema, error)
-	GetAvailableSeats(ctx context.Context, slug string, groupSize int) ([][]models.Seat, error)
-	CheckAvailableSeats(ctx context.Context, slug string, req *models.CheckSeatsRequest) ([]models.Seat, error)
+	GetAvailableSeats(ctx context.Context, caller *models.Caller, slug string, showtimeID uint, query *models.AvailableSeatsQuery) (*models.AvailableSeats, error)
+	CheckAvailableSeats(ctx context.Context, slug string, showtimeID uint, req *models.CheckSeatsRequest) ([]models.Seat, error)
+	RecommendSeats(ctx context.Context, slug string, showtimeID uint, groupSize, limit int) ([]models.SeatRecommendation, error)
+	GetSeatMap(ctx context.Context, slug string, showtimeID uint) (*models.SeatMap, error)
+	GetSeatPrices(ctx context.Context, slug string) ([]models.SeatPrice, error)
+	SetSeatPrices(ctx context.Context, slug string, req *models.SetSeatPricesRequest) ([]models.SeatPrice, error)
+	WatchSeatMap(ctx context.Context, slug string, showtimeID uint) (*models.SeatMap, <-chan *models.SeatMapDelta, error)
+}
+
+type SeatEventHub interface {
+	Run(ctx context.Context)
+	Subscribe(showtimeID uint) (<-chan struct{}, func())
+}
+
+type MovieService interface {
+	CreateMovie(ctx context.Context, req *models.CreateMovieRequest) (*models.Movie, error)
+	ListMovies(ctx context.Context) ([]models.Movie, error)
+}
+
+type ShowtimeService interface {
+	CreateShowtime(ctx context.Context, cinemaSlug string, req *models.ShowtimeRequest) (*models.Showtime, error)
+	ListShowtimes(ctx context.Context, cinemaSlug string) ([]models.Showtime, error)
+	GetShowtime(ctx context.Context, cinemaSlug string, showtimeID uint) (*models.Showtime, error)
+	UpdateShowtime(ctx context.Context, cinemaSlug string, showtimeID uint, req *models.ShowtimeRequest) (*models.Showtime, error)
+	DeleteShowtime(ctx context.Context, cinemaSlug string, showtimeID uint) error
+}
+
+type AuthService interface {
+	Register(ctx context.Context, req *models.RegisterRequest) (*models.AuthToken, error)
+	Login(ctx context.Context, req *models.LoginRequest) (*models.AuthToken, error)
+	Authenticate(ctx context.Context, token string) (*models.Caller, error)
+	AuthorizeCinema(ctx context.Context, caller *models.Caller, slug string) error
+	SetUserRole(ctx context.Context, userID uint, req *models.SetUserRoleRequest) (*models.User, error)
+}
+
+type APIKeyService interface {
+	CreateKey(ctx context.Context, req *models.CreateAPIKeyRequest) (*models.IssuedAPIKey, error)
+	ListKeys(ctx context.Context) ([]models.APIKey, error)
+	RevokeKey(ctx context.Context, id uint) error
+	Authenticate(ctx context.Context, secret string) (*models.APIKey, error)
+	RecordUsage(ctx context.Context, keyID uint)
+	GetUsage(ctx context.Context, id uint, from, to time.Time) ([]models.APIKeyUsage, error)
 }
 
 type ReservationService interface {
-	ReserveSeats(ctx context.Context, req *models.ReservationRequest) (*models.Reservation, error)
-	CancelSeats(ctx context.Context, req *models.CancelRequest) error
+	ReserveSeats(ctx context.Context, caller *models.Caller, req *models.ReservationRequest) (*models.Reservation, error)
+	CancelSeats(ctx context.Context, caller *models.Caller, req *models.CancelRequest) error
+	GetReservation(ctx context.Context, caller *models.Caller, reservationID uint) (*models.Reservation, error)
+	GetReservationByCode(ctx context.Context, caller *models.Caller, code string) (*models.Reservation, error)
+	ListUserReservations(ctx context.Context, caller *models.Caller) ([]models.Reservation, error)
+	CancelReservation(ctx context.Context, caller *models.Caller, reservationID uint) error
+	CancelReservationSeats(ctx context.Context, caller *models.Caller, reservationID uint, req *models.CancelReservationSeatsRequest) error
+	HoldSeats(ctx context.Context, caller *models.Caller, req *models.HoldRequest) (*models.Hold, error)
+	ConfirmHold(ctx context.Context, caller *models.Caller, holdID string, req *models.ConfirmHoldRequest) (*models.Reservation, error)
+	ReleaseHold(ctx context.Context, caller *models.Caller, holdID string) error
+	ReleaseExpiredHolds(ctx context.Context) (int, error)
+	StartHoldSweeper(ctx context.Context, interval time.Duration)
+}
+
+type SeatReleaseService interface {
+	Enqueue(ctx context.Context, showtimeID uint, seats []models.Seat, reason string, cause error)
+	ProcessDue(ctx context.Context) (int, error)
+	StartWorker(ctx context.Context, interval time.Duration)
+	ListReleases(ctx context.Context, status string) ([]models.SeatRelease, error)
+	RetryRelease(ctx context.Context, id uint) (*models.SeatRelease, error)
+	DiscardRelease(ctx context.Context, id uint) error
 }
 
 type AppService interface {
 	SyncReservationsToRedis() error
+	ResyncAll(ctx context.Context) (*models.ResyncReport, error)
+	ResyncCinema(ctx context.Context, slug string) (*models.ResyncReport, error)
+	ReconcileSeats(ctx context.Context) (*models.ReconcileReport, error)
+	StartReconciler(ctx context.Context, interval time.Duration)
 }
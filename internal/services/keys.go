@@ -0,0 +1,62 @@
+package services
+
+import "fmt"
+
+const (
+	// holdExpiryKey is a sorted set of hold IDs scored by their expiry in
+	// milliseconds, used by the sweeper to find expired holds.
+	holdExpiryKey = "holds:expiry"
+	// holdShowtimesKey maps a hold ID to the showtime it belongs to.
+	holdShowtimesKey = "holds:showtimes"
+	// seatEventsPattern matches the pub/sub channels of every showtime.
+	seatEventsPattern = "showtime:*:seat_events"
+)
+
+// showtimeSeatsKey is the Redis hash holding the occupied seats ("row:col")
+// of a single showtime. The value is "1" for a reservation and
+// "hold:{holdID}" for a seat on hold.
+func showtimeSeatsKey(showtimeID uint) string {
+	return fmt.Sprintf("showtime:%d:seats", showtimeID)
+}
+
+// showtimeHoldsKey is the Redis hash of the active holds of a showtime,
+// mapping the hold ID to its expiry and seats.
+func showtimeHoldsKey(showtimeID uint) string {
+	return fmt.Sprintf("showtime:%d:holds", showtimeID)
+}
+
+// showtimeHoldExpiryKey is a sorted set of the hold IDs of a showtime scored
+// by their expiry in milliseconds, so a reservation only looks at the
+// expired holds of its own showtime.
+func showtimeHoldExpiryKey(showtimeID uint) string {
+	return fmt.Sprintf("showtime:%d:holds:expiry", showtimeID)
+}
+
+// holdScriptKeys are the KEYS shared by every script that touches holds.
+func holdScriptKeys(showtimeID uint) []string {
+	return []string{
+		showtimeSeatsKey(showtimeID),
+		showtimeHoldsKey(showtimeID),
+		holdExpiryKey,
+		holdShowtimesKey,
+		showtimeHoldExpiryKey(showtimeID),
+	}
+}
+
+// cinemaLayoutKey is the Redis string holding the layout of a cinema with
+// the rows concatenated, so the cell at (row, col) is at row*columns+col.
+func cinemaLayoutKey(cinemaID uint) string {
+	return fmt.Sprintf("cinema:%d:layout", cinemaID)
+}
+
+// showtimeSeatEventsChannel is the pub/sub channel carrying the seat changes
+// of a showtime.
+func showtimeSeatEventsChannel(showtimeID uint) string {
+	return fmt.Sprintf("showtime:%d:seat_events", showtimeID)
+}
+
+// apiKeyUsageKey is the Redis hash counting the requests of an API key per
+// day ("2006-01-02", UTC).
+func apiKeyUsageKey(apiKeyID uint) string {
+	return fmt.Sprintf("api_key:%d:usage", apiKeyID)
+}
@@ -0,0 +1,37 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"cinema-reservation/internal/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// storeCinemaLayout copies the layout of an irregular cinema to Redis, where
+// reserve.lua checks that every requested cell is a seat. Layouts never
+// change, so the key has no expiry.
+func storeCinemaLayout(ctx context.Context, rdb *redis.Client, cinema *models.Cinema) error {
+	if len(cinema.Layout) == 0 {
+		return nil
+	}
+	return rdb.Set(ctx, cinemaLayoutKey(cinema.ID), strings.Join(cinema.Layout, ""), 0).Err()
+}
+
+// layoutColumns is the row width reserve.lua uses to find a cell in the
+// stored layout, 0 when the cinema is a plain rectangle.
+func layoutColumns(cinema *models.Cinema) int {
+	if len(cinema.Layout) == 0 {
+		return 0
+	}
+	return cinema.Columns
+}
+
+// spanAislesArg tells reserve.lua whether seats across an aisle sit together.
+func spanAislesArg(cinema *models.Cinema) string {
+	if cinema.BlocksSpanAisles {
+		return "1"
+	}
+	return "0"
+}
@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"strings"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/repositories"
+	"cinema-reservation/internal/utils"
+
+	"github.com/gosimple/slug"
+	"github.com/sirupsen/logrus"
+)
+
+type movieService struct {
+	movieRepo repositories.MovieRepository
+}
+
+func NewMovieService(movieRepo repositories.MovieRepository) MovieService {
+	return &movieService{movieRepo: movieRepo}
+}
+
+func (s *movieService) CreateMovie(ctx context.Context, req *models.CreateMovieRequest) (*models.Movie, error) {
+	title := strings.TrimSpace(req.Title)
+
+	exists, err := s.movieRepo.ExistsByTitle(ctx, title)
+	if err != nil {
+		logrus.WithError(err).Error("failed to check movie title existence")
+		return nil, utils.ErrInternalServer
+	}
+	if exists {
+		return nil, utils.ErrMovieAlreadyExists
+	}
+
+	movie := &models.Movie{
+		Title:           title,
+		Slug:            slug.Make(title),
+		DurationMinutes: req.DurationMinutes,
+	}
+
+	err = s.movieRepo.Create(ctx, movie)
+	if err != nil {
+		logrus.WithError(err).Error("failed to create movie")
+		return nil, utils.ErrInternalServer
+	}
+
+	return movie, nil
+}
+
+func (s *movieService) ListMovies(ctx context.Context) ([]models.Movie, error) {
+	movies, err := s.movieRepo.List(ctx)
+	if err != nil {
+		logrus.WithError(err).Error("failed to list movies")
+		return nil, utils.ErrInternalServer
+	}
+	return movies, nil
+}
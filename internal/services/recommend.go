@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/utils"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Weights of the recommendation criteria. They add up to 1 so a score stays
+// between 0 and 1.
+const (
+	recommendRowWeight    = 0.4
+	recommendCenterWeight = 0.3
+	recommendWasteWeight  = 0.3
+)
+
+// sweetSpotRowRatio places the ideal row two thirds of the way back from the
+// screen, row 0 being the closest to the screen.
+const sweetSpotRowRatio = 2.0 / 3.0
+
+// RecommendSeats ranks the safe blocks of groupSize seats and returns the
+// best limit of them.
+func (s *cinemaService) RecommendSeats(ctx context.Context, slug string, showtimeID uint, groupSize, limit int) ([]models.SeatRecommendation, error) {
+	cinema, showtime, err := s.getCinemaShowtime(ctx, slug, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+
+	seats, err := s.seats.get(ctx, cinema, showtime.ID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get reserved seats from redis")
+		return nil, utils.ErrInternalServer
+	}
+
+	heatmap := seats.heat
+	blocks := filterAccessibleBlocks(showtime, FindSafeBlocks(cinema, heatmap, groupSize), models.AccessibilityNone, time.Now())
+
+	recommendations := make([]models.SeatRecommendation, 0, len(blocks))
+	for _, block := range blocks {
+		recommendations = append(recommendations, models.SeatRecommendation{
+			Seats: block,
+			Score: scoreBlock(cinema, heatmap, block),
+		})
+	}
+
+	// Stable keeps scan order among equal scores, so results are deterministic
+	sort.SliceStable(recommendations, func(i, j int) bool {
+		return recommendations[i].Score > recommendations[j].Score
+	})
+
+	if len(recommendations) > limit {
+		recommendations = recommendations[:limit]
+	}
+
+	return recommendations, nil
+}
+
+// scoreBlock rates a horizontal block of safe seats by how close its row is to
+// the sweet spot, how centered it is in the row, and how few other safe seats
+// it makes unusable through the distance rule.
+func scoreBlock(cinema *models.Cinema, heat [][]bool, block []models.Seat) float64 {
+	rows := len(heat)
+	cols := len(heat[0])
+	row := block[0].Row
+	first := block[0].Column
+	last := block[len(block)-1].Column
+
+	rowScore := 1.0
+	if rows > 1 {
+		sweetSpot := sweetSpotRowRatio * float64(rows-1)
+		maxOffset := math.Max(sweetSpot, float64(rows-1)-sweetSpot)
+		rowScore = 1 - math.Abs(float64(row)-sweetSpot)/maxOffset
+	}
+
+	centerScore := 1.0
+	if slack := float64(cols - (last - first + 1)); slack > 0 {
+		offset := math.Abs(float64(first+last)/2 - float64(cols-1)/2)
+		centerScore = 1 - offset/(slack/2)
+	}
+
+	wasteScore := 1.0
+	if wasted, maxWasted := countWastedSeats(cinema, heat, row, first, last); maxWasted > 0 {
+		wasteScore = 1 - float64(wasted)/float64(maxWasted)
+	}
+
+	return recommendRowWeight*rowScore + recommendCenterWeight*centerScore + recommendWasteWeight*wasteScore
+}
+
+// countWastedSeats counts the safe seats outside the block that would become
+// blocked by distance once the block is taken, along with the size of the
+// neighbourhood they were counted in.
+func countWastedSeats(cinema *models.Cinema, heat [][]bool, row, first, last int) (int, int) {
+	minDist := cinema.MinDistance
+	if minDist <= 1 {
+		return 0, 0
+	}
+
+	rows := len(heat)
+	cols := len(heat[0])
+	wasted, total := 0, 0
+	for r := max(row-minDist+1, 0); r <= min(row+minDist-1, rows-1); r++ {
+		for c := max(first-minDist+1, 0); c <= min(last+minDist-1, cols-1); c++ {
+			if r == row && c >= first && c <= last {
+				continue
+			}
+			if !cinema.HasSeat(r, c) || !tooCloseToBlock(cinema, r, c, row, first, last) {
+				continue
+			}
+			total++
+			if !heat[r][c] {
+				wasted++
+			}
+		}
+	}
+
+	return wasted, total
+}
+
+// tooCloseToBlock reports whether a cell is too close to the closest seat of
+// a horizontal block under the distance policy of the cinema.
+func tooCloseToBlock(cinema *models.Cinema, r, c, row, first, last int) bool {
+	dc := 0
+	if c < first {
+		dc = first - c
+	} else if c > last {
+		dc = c - last
+	}
+	return cinema.DistancePolicy.TooClose(r-row, dc, cinema.MinDistance)
+}
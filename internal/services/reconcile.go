@@ -0,0 +1,215 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"cinema-reservation/internal/models"
+
+	"github.com/sirupsen/logrus"
+)
+
+// ReconcileSeats compares the seat hash of every showtime with the active
+// ReservedSeat rows and repairs Redis towards the DB.
+//
+// A reservation writes Redis before the DB and a cancellation writes the DB
+// before Redis, so a single pass can observe drift that is only in flight.
+// A difference is therefore fixed only when the previous pass saw it too.
+// Held seats live only in Redis and are left alone.
+func (s *appService) ReconcileSeats(ctx context.Context) (*models.ReconcileReport, error) {
+	report := &models.ReconcileReport{StartedAt: time.Now()}
+
+	showtimeIDs, err := s.knownShowtimeIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	drift := make(map[models.SeatFix]struct{})
+	for _, showtimeID := range showtimeIDs {
+		fixes, err := s.findSeatDrift(ctx, showtimeID)
+		if err != nil {
+			return nil, err
+		}
+		for _, fix := range fixes {
+			drift[fix] = struct{}{}
+		}
+		report.ShowtimesChecked++
+	}
+
+	s.mu.Lock()
+	previous := s.suspects
+	s.suspects = drift
+	s.mu.Unlock()
+
+	for fix := range drift {
+		if _, seen := previous[fix]; !seen {
+			continue
+		}
+
+		applied, err := s.applySeatFix(ctx, fix)
+		if err != nil {
+			return nil, err
+		}
+		if !applied {
+			continue
+		}
+
+		report.Fixes = append(report.Fixes, fix)
+		if fix.Action == models.SeatFixRestored {
+			report.SeatsRestored++
+		} else {
+			report.SeatsReleased++
+		}
+		logrus.WithFields(logrus.Fields{
+			"showtime_id": fix.ShowtimeID,
+			"row":         fix.Row,
+			"column":      fix.Column,
+			"action":      fix.Action,
+		}).Warn("Reconciler repaired seat drift between Redis and DB")
+	}
+
+	report.Duration = time.Since(report.StartedAt)
+	return report, nil
+}
+
+// StartReconciler runs ReconcileSeats every interval until ctx is done.
+func (s *appService) StartReconciler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			report, err := s.ReconcileSeats(ctx)
+			if err != nil {
+				logrus.WithError(err).Error("failed to reconcile seats")
+				continue
+			}
+			if len(report.Fixes) > 0 {
+				logrus.Printf("Reconciled %d showtimes in %v: %d seats restored, %d seats released",
+					report.ShowtimesChecked, report.Duration, report.SeatsRestored, report.SeatsReleased)
+			}
+		}
+	}
+}
+
+// knownShowtimeIDs returns every showtime that has not ended and has reserved
+// seats in the DB, and every showtime with a seat hash in Redis. The seats of
+// past showtimes stay in the DB for good, so they are only checked while
+// Redis still has their hash.
+func (s *appService) knownShowtimeIDs(ctx context.Context) ([]uint, error) {
+	showtimeIDs, err := s.reservationRepo.ListReservedShowtimeIDs(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("failed to list reserved showtimes: %w", err)
+	}
+
+	seen := make(map[uint]bool, len(showtimeIDs))
+	for _, id := range showtimeIDs {
+		seen[id] = true
+	}
+
+	iter := s.redis.Scan(ctx, 0, "showtime:*:seats", 100).Iterator()
+	for iter.Next(ctx) {
+		id, ok := parseShowtimeSeatsKey(iter.Val())
+		if ok && !seen[id] {
+			seen[id] = true
+			showtimeIDs = append(showtimeIDs, id)
+		}
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("failed to scan seat keys: %w", err)
+	}
+
+	return showtimeIDs, nil
+}
+
+func (s *appService) findSeatDrift(ctx context.Context, showtimeID uint) ([]models.SeatFix, error) {
+	reservedSeats, err := s.reservationRepo.GetReservedSeatsByShowtime(ctx, showtimeID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch reserved seats of showtime %d: %w", showtimeID, err)
+	}
+
+	redisSeats, err := s.redis.HGetAll(ctx, showtimeSeatsKey(showtimeID)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch seats of showtime %d from redis: %w", showtimeID, err)
+	}
+
+	var fixes []models.SeatFix
+	inDB := make(map[string]bool, len(reservedSeats))
+	for _, seat := range reservedSeats {
+		seatKey := fmt.Sprintf("%d:%d", seat.Row, seat.Column)
+		inDB[seatKey] = true
+		if _, ok := redisSeats[seatKey]; !ok {
+			fixes = append(fixes, models.SeatFix{
+				ShowtimeID: showtimeID,
+				Row:        seat.Row,
+				Column:     seat.Column,
+				Action:     models.SeatFixRestored,
+			})
+		}
+	}
+
+	for seatKey, value := range redisSeats {
+		if inDB[seatKey] || strings.HasPrefix(value, "hold:") {
+			continue
+		}
+		row, column, err := parseSeatKey(seatKey)
+		if err != nil {
+			logrus.WithError(err).WithField("showtime_id", showtimeID).Warn("skipping malformed seat in redis")
+			continue
+		}
+		fixes = append(fixes, models.SeatFix{
+			ShowtimeID: showtimeID,
+			Row:        row,
+			Column:     column,
+			Action:     models.SeatFixReleased,
+		})
+	}
+
+	return fixes, nil
+}
+
+// applySeatFix writes a single repair to Redis. It only touches the seat if it
+// is still in the state the reconciler observed.
+func (s *appService) applySeatFix(ctx context.Context, fix models.SeatFix) (bool, error) {
+	key := showtimeSeatsKey(fix.ShowtimeID)
+	seatKey := fmt.Sprintf("%d:%d", fix.Row, fix.Column)
+	seats := []models.Seat{{Row: fix.Row, Column: fix.Column}}
+
+	if fix.Action == models.SeatFixRestored {
+		applied, err := s.redis.HSetNX(ctx, key, seatKey, "1").Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to restore seat %s of showtime %d: %w", seatKey, fix.ShowtimeID, err)
+		}
+		if applied {
+			publishSeatEvent(ctx, s.redis, fix.ShowtimeID, models.SeatEventReserved, seats)
+		}
+		return applied, nil
+	}
+
+	// Only a seat still reserved is freed, in one step, so a hold or a new
+	// reservation taken since the check stays
+	released, err := cancelSeatsRedis(ctx, s.redis, s.scripts, fix.ShowtimeID, seats)
+	if err != nil {
+		return false, fmt.Errorf("failed to release seat %s of showtime %d: %w", seatKey, fix.ShowtimeID, err)
+	}
+	return len(released) > 0, nil
+}
+
+// parseShowtimeSeatsKey extracts the showtime ID from "showtime:{id}:seats".
+func parseShowtimeSeatsKey(key string) (uint, bool) {
+	parts := strings.Split(key, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
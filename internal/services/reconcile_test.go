@@ -0,0 +1,57 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cinema-reservation/internal/models"
+	scriptloader "cinema-reservation/internal/scripts"
+)
+
+// TestReconcileReleasesOnlyReservedSeats releases two seats the reconciler
+// saw as drift, one of which was held by then.
+func TestReconcileReleasesOnlyReservedSeats(t *testing.T) {
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	showtimeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	key := showtimeSeatsKey(showtimeID)
+	t.Cleanup(func() { rdb.Del(ctx, key) })
+
+	err = rdb.HSet(ctx, key, "0:0", "1", "0:1", "hold:abc").Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := &appService{redis: rdb, scripts: scripts}
+	tests := []struct {
+		column      int
+		wantApplied bool
+		wantValue   string
+	}{
+		{0, true, ""},
+		{1, false, "hold:abc"},
+	}
+	for _, tt := range tests {
+		applied, err := service.applySeatFix(ctx, models.SeatFix{
+			ShowtimeID: showtimeID,
+			Row:        0,
+			Column:     tt.column,
+			Action:     models.SeatFixReleased,
+		})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if applied != tt.wantApplied {
+			t.Errorf("seat 0:%d released = %v, want %v", tt.column, applied, tt.wantApplied)
+		}
+		if got := rdb.HGet(ctx, key, seatKeys([]models.Seat{{Row: 0, Column: tt.column}})[0]).Val(); got != tt.wantValue {
+			t.Errorf("seat 0:%d = %q, want %q", tt.column, got, tt.wantValue)
+		}
+	}
+}
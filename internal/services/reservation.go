@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
 	"cinema-reservation/internal/models"
 	"cinema-reservation/internal/repositories"
@@ -15,74 +16,142 @@ import (
 )
 
 type reservationService struct {
-	reservationRepo repositories.ReservationRepository
-	cinemaRepo      repositories.CinemaRepository
-	redis           *redis.Client
+	reservationRepo    repositories.ReservationRepository
+	showtimeRepo       repositories.ShowtimeRepository
+	seatPriceRepo      repositories.SeatPriceRepository
+	seatReleaseService SeatReleaseService
+	redis              *redis.Client
+	scripts            *scriptloader.Registry
+	holdTTL            time.Duration
 }
 
 func NewReservationService(
 	reservationRepo repositories.ReservationRepository,
-	cinemaRepo repositories.CinemaRepository,
+	showtimeRepo repositories.ShowtimeRepository,
+	seatPriceRepo repositories.SeatPriceRepository,
+	seatReleaseService SeatReleaseService,
 	redis *redis.Client,
+	scripts *scriptloader.Registry,
+	holdTTL time.Duration,
 ) ReservationService {
 	return &reservationService{
-		reservationRepo: reservationRepo,
-		cinemaRepo:      cinemaRepo,
-		redis:           redis,
+		reservationRepo:    reservationRepo,
+		showtimeRepo:       showtimeRepo,
+		seatPriceRepo:      seatPriceRepo,
+		seatReleaseService: seatReleaseService,
+		redis:              redis,
+		scripts:            scripts,
+		holdTTL:            holdTTL,
 	}
 }
 
-func (s *reservationService) ReserveSeats(ctx context.Context, req *models.ReservationRequest) (*models.Reservation, error) {
-	// Get cinema
-	cinema, err := s.cinemaRepo.GetBySlug(ctx, req.CinemaSlug)
+func (s *reservationService) ReserveSeats(ctx context.Context, caller *models.Caller, req *models.ReservationRequest) (*models.Reservation, error) {
+	// Get showtime
+	showtime, err := s.getShowtime(ctx, req.ShowtimeID)
 	if err != nil {
-		logrus.WithError(err).Error("failed to get cinema by slug")
-		return nil, utils.ErrInternalServer
+		return nil, err
+	}
+	if !showtime.StartsAt.After(time.Now()) {
+		return nil, utils.ErrShowtimeAlreadyStarted
 	}
-	if cinema == nil {
-		return nil, utils.ErrCinemaNotFound
+	if !caller.CanBook(showtime.CinemaID) {
+		return nil, utils.ErrCinemaNotInKeyScope
 	}
 
 	// Validate seats
-	var reservedSeats []models.ReservedSeat
-	for _, seat := range req.Seats {
-		if seat.Row < 0 || seat.Row >= cinema.Rows || seat.Column < 0 || seat.Column >= cinema.Columns {
-			return nil, utils.ErrInvalidSeatPosition
-		}
-		reservedSeats = append(reservedSeats, models.ReservedSeat{
-			CinemaID: cinema.ID,
-			Row:      seat.Row,
-			Column:   seat.Column,
-		})
+	reservedSeats, err := buildReservedSeats(showtime, req.Seats, req.Accessibility)
+	if err != nil {
+		return nil, err
 	}
 
-	err = s.reserveSeatsRedis(ctx, cinema.ID, reservedSeats, cinema.MinDistance)
+	partyID, partySeats, err := findParty(ctx, s.reservationRepo, caller, showtime, req.PartyCode)
 	if err != nil {
 		return nil, err
 	}
 
-	// Create reservation
+	err = s.reserveSeatsRedis(ctx, caller, showtime, reservedSeats, partyID, partySeats, "", time.Time{})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.createReservation(ctx, caller, showtime, reservedSeats, partyID, req.Note)
+}
+
+// findParty looks up the party of the reservation with the booking code and
+// its active seats. Without a code the booking is not part of a party. Only
+// callers who may access the reservation can join its party, for anyone else
+// the code does not exist, so codes cannot be probed.
+func findParty(ctx context.Context, reservationRepo repositories.ReservationRepository, caller *models.Caller, showtime *models.Showtime, code string) (*uint, []models.Seat, error) {
+	if code == "" {
+		return nil, nil, nil
+	}
+	if caller == nil {
+		return nil, nil, utils.ErrUnauthorized
+	}
+
+	reservation, err := reservationRepo.GetByCode(ctx, utils.NormalizeBookingCode(code))
+	if err != nil {
+		logrus.WithError(err).Error("failed to get party reservation by code")
+		return nil, nil, utils.ErrInternalServer
+	}
+	if reservation == nil || !caller.CanAccess(reservation) {
+		return nil, nil, utils.ErrPartyNotFound
+	}
+	if reservation.ShowtimeID != showtime.ID {
+		return nil, nil, utils.ErrPartyShowtimeMismatch
+	}
+
+	partyID := reservation.ID
+	if reservation.PartyID != nil {
+		partyID = *reservation.PartyID
+	}
+
+	partySeats, err := reservationRepo.GetPartySeats(ctx, partyID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get party seats")
+		return nil, nil, utils.ErrInternalServer
+	}
+
+	return &partyID, models.ReservedSeats(partySeats).Seats(), nil
+}
+
+// createReservation persists a reservation of the caller for seats that are
+// already taken in Redis, releasing them again if the insert fails.
+func (s *reservationService) createReservation(ctx context.Context, caller *models.Caller, showtime *models.Showtime, reservedSeats []models.ReservedSeat, partyID *uint, note string) (*models.Reservation, error) {
 	reservation := &models.Reservation{
-		CinemaID: cinema.ID,
-		Note:     req.Note,
-		Seats:    reservedSeats,
+		CinemaID:   showtime.CinemaID,
+		ShowtimeID: showtime.ID,
+		Note:       note,
+		PartyID:    partyID,
+		Seats:      reservedSeats,
+	}
+	if caller.Role == models.UserRolePartner {
+		reservation.APIKeyID = &caller.APIKeyID
+	} else {
+		reservation.UserID = &caller.UserID
 	}
 
-	err = s.reservationRepo.Create(ctx, reservation)
+	err := s.priceReservation(ctx, showtime, reservation)
+	if err == nil {
+		reservation.Code, err = s.generateBookingCode(ctx)
+	}
+	if err == nil {
+		err = s.reservationRepo.Create(ctx, reservation)
+	}
 	if err != nil {
-		cancelErr := s.cancelSeatsRedis(ctx, cinema.ID, reservedSeats)
+		seats := models.ReservedSeats(reservedSeats).Seats()
+		_, cancelErr := cancelSeatsRedis(ctx, s.redis, s.scripts, showtime.ID, seats)
 		if cancelErr != nil {
 			logrus.WithFields(logrus.Fields{
-				"cinema_id":      cinema.ID,
+				"showtime_id":    showtime.ID,
 				"reserved_seats": models.ReservedSeats(reservedSeats).String(),
 				"rollback_error": cancelErr.Error(),
 				"original_error": err.Error(),
 				"operation":      "seat_reservation_rollback",
-			}).Error("CRITICAL: Failed to rollback reserved seats on Redis after reservation creation failed - manual intervention required")
+			}).Error("Failed to rollback reserved seats on Redis after reservation creation failed - queued for retry")
+			s.seatReleaseService.Enqueue(ctx, showtime.ID, seats, models.SeatReleaseReasonRollback, cancelErr)
 		}
 
-		// TODO: Add retry mechanism and send notification to admin if totally failed
-
 		logrus.WithError(err).Error("insert reservation to DB failed")
 		return nil, utils.ErrInternalServer
 	}
@@ -90,23 +159,17 @@ func (s *reservationService) ReserveSeats(ctx context.Context, req *models.Reser
 	return reservation, nil
 }
 
-func (s *reservationService) CancelSeats(ctx context.Context, req *models.CancelRequest) error {
-	// Get cinema
-	cinema, err := s.cinemaRepo.GetBySlug(ctx, req.CinemaSlug)
+func (s *reservationService) CancelSeats(ctx context.Context, caller *models.Caller, req *models.CancelRequest) error {
+	// Get showtime
+	showtime, err := s.getShowtime(ctx, req.ShowtimeID)
 	if err != nil {
-		logrus.WithError(err).Error("failed to get cinema by slug")
-		return utils.ErrInternalServer
-	}
-	if cinema == nil {
-		return utils.ErrCinemaNotFound
+		return err
 	}
+	cinema := showtime.Cinema
 
-	var (
-		seats           []models.Seat
-		seatIDsToCancel []uint
-	)
+	var seats []models.Seat
 	for _, seat := range req.Seats {
-		if seat.Row < 0 || seat.Row >= cinema.Rows || seat.Column < 0 || seat.Column >= cinema.Columns {
+		if !cinema.HasSeat(seat.Row, seat.Column) {
 			return utils.ErrInvalidSeatPosition
 		}
 		seats = append(seats, models.Seat{
@@ -115,7 +178,7 @@ func (s *reservationService) CancelSeats(ctx context.Context, req *models.Cancel
 		})
 	}
 
-	reservedSeats, err := s.reservationRepo.FindReservedSeats(ctx, cinema.ID, seats)
+	reservedSeats, err := s.reservationRepo.FindReservedSeats(ctx, showtime.ID, seats)
 	if err != nil {
 		logrus.WithError(err).Error("failed to find reserved seats")
 		return utils.ErrInternalServer
@@ -126,44 +189,260 @@ func (s *reservationService) CancelSeats(ctx context.Context, req *models.Cancel
 		return utils.ErrSeatsNotReserved
 	}
 
+	err = s.checkSeatOwnership(ctx, caller, reservedSeats)
+	if err != nil {
+		return err
+	}
+
+	return s.cancelReservedSeats(ctx, showtime.ID, reservedSeats)
+}
+
+// checkSeatOwnership makes sure the caller may cancel every reservation the
+// seats belong to.
+func (s *reservationService) checkSeatOwnership(ctx context.Context, caller *models.Caller, reservedSeats []models.ReservedSeat) error {
+	checked := make(map[uint]bool)
+	for _, seat := range reservedSeats {
+		if checked[seat.ReservationID] {
+			continue
+		}
+		checked[seat.ReservationID] = true
+
+		reservation, err := s.reservationRepo.GetByID(ctx, seat.ReservationID)
+		if err != nil {
+			logrus.WithError(err).Error("failed to get reservation of seat")
+			return utils.ErrInternalServer
+		}
+		if reservation == nil || !caller.CanAccess(reservation) {
+			return utils.ErrNotReservationOwner
+		}
+	}
+
+	return nil
+}
+
+// GetReservation returns the reservation if the caller may access it. Other
+// users' reservations are reported as not found.
+func (s *reservationService) GetReservation(ctx context.Context, caller *models.Caller, reservationID uint) (*models.Reservation, error) {
+	reservation, err := s.reservationRepo.GetByID(ctx, reservationID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get reservation by id")
+		return nil, utils.ErrInternalServer
+	}
+	if reservation == nil || !caller.CanAccess(reservation) {
+		return nil, utils.ErrReservationNotFound
+	}
+	return reservation, nil
+}
+
+func (s *reservationService) GetReservationByCode(ctx context.Context, caller *models.Caller, code string) (*models.Reservation, error) {
+	reservation, err := s.reservationRepo.GetByCode(ctx, utils.NormalizeBookingCode(code))
+	if err != nil {
+		logrus.WithError(err).Error("failed to get reservation by code")
+		return nil, utils.ErrInternalServer
+	}
+	if reservation == nil || !caller.CanAccess(reservation) {
+		return nil, utils.ErrReservationNotFound
+	}
+	return reservation, nil
+}
+
+// ListUserReservations returns the reservations made by the caller, that is
+// by the user or with the API key.
+func (s *reservationService) ListUserReservations(ctx context.Context, caller *models.Caller) ([]models.Reservation, error) {
+	var reservations []models.Reservation
+	var err error
+	if caller.Role == models.UserRolePartner {
+		reservations, err = s.reservationRepo.ListByAPIKey(ctx, caller.APIKeyID)
+	} else {
+		reservations, err = s.reservationRepo.ListByUser(ctx, caller.UserID)
+	}
+	if err != nil {
+		logrus.WithError(err).Error("failed to list reservations of user")
+		return nil, utils.ErrInternalServer
+	}
+	return reservations, nil
+}
+
+func (s *reservationService) CancelReservation(ctx context.Context, caller *models.Caller, reservationID uint) error {
+	reservation, err := s.GetReservation(ctx, caller, reservationID)
+	if err != nil {
+		return err
+	}
+
+	return s.cancelReservedSeats(ctx, reservation.ShowtimeID, reservation.Seats)
+}
+
+func (s *reservationService) CancelReservationSeats(ctx context.Context, caller *models.Caller, reservationID uint, req *models.CancelReservationSeatsRequest) error {
+	reservation, err := s.GetReservation(ctx, caller, reservationID)
+	if err != nil {
+		return err
+	}
+
+	owned := make(map[models.Seat]models.ReservedSeat, len(reservation.Seats))
+	for _, seat := range reservation.Seats {
+		owned[models.Seat{Row: seat.Row, Column: seat.Column}] = seat
+	}
+
+	var reservedSeats []models.ReservedSeat
+	for _, seat := range req.Seats {
+		reservedSeat, ok := owned[models.Seat{Row: seat.Row, Column: seat.Column}]
+		if !ok {
+			return utils.ErrSeatsNotInReservation
+		}
+		// Ignore duplicates so a seat is not canceled twice
+		delete(owned, models.Seat{Row: seat.Row, Column: seat.Column})
+		reservedSeats = append(reservedSeats, reservedSeat)
+	}
+
+	return s.cancelReservedSeats(ctx, reservation.ShowtimeID, reservedSeats)
+}
+
+// cancelReservedSeats soft deletes the seats in the DB and then frees them in
+// Redis. The DB is the source of truth, so a Redis failure is queued for a
+// retry instead of failing the request.
+func (s *reservationService) cancelReservedSeats(ctx context.Context, showtimeID uint, reservedSeats []models.ReservedSeat) error {
+	var seatIDsToCancel []uint
 	for _, seat := range reservedSeats {
 		seatIDsToCancel = append(seatIDsToCancel, seat.ID)
 	}
-	err = s.reservationRepo.CancelSeats(ctx, seatIDsToCancel)
+	err := s.reservationRepo.CancelSeats(ctx, seatIDsToCancel)
 	if err != nil {
 		logrus.WithError(err).Error("failed to cancel seats")
 		return utils.ErrInternalServer
 	}
 
-	cancelErr := s.cancelSeatsRedis(ctx, cinema.ID, reservedSeats)
+	seats := models.ReservedSeats(reservedSeats).Seats()
+	_, cancelErr := cancelSeatsRedis(ctx, s.redis, s.scripts, showtimeID, seats)
 	if cancelErr != nil {
 		logrus.WithFields(logrus.Fields{
-			"cinema_id":      cinema.ID,
+			"showtime_id":    showtimeID,
 			"reserved_seats": models.ReservedSeats(reservedSeats).String(),
 			"cancel_error":   cancelErr.Error(),
 			"operation":      "seat_reservation_cancel",
-		}).Error("CRITICAL: Failed to cancel reserved seats on Redis - manual intervention required")
+		}).Error("Failed to cancel reserved seats on Redis - queued for retry")
+		s.seatReleaseService.Enqueue(ctx, showtimeID, seats, models.SeatReleaseReasonCancel, cancelErr)
 	}
 
-	// TODO: Add retry mechanism and send notification to admin if totally failed
+	return nil
+}
+
+// priceReservation stores the category and current price on every seat and
+// their sum as the total of the reservation. Categories without a price are
+// free.
+func (s *reservationService) priceReservation(ctx context.Context, showtime *models.Showtime, reservation *models.Reservation) error {
+	prices, err := s.seatPriceRepo.ListByCinema(ctx, showtime.CinemaID)
+	if err != nil {
+		return fmt.Errorf("failed to fetch seat prices: %w", err)
+	}
+
+	amounts := make(map[models.SeatCategory]int64, len(prices))
+	for _, price := range prices {
+		amounts[price.Category] = price.Amount
+	}
+
+	reservation.Total = 0
+	for i := range reservation.Seats {
+		seat := &reservation.Seats[i]
+		seat.Category = showtime.Cinema.SeatCategory(seat.Row, seat.Column)
+		seat.Price = amounts[seat.Category]
+		reservation.Total += seat.Price
+	}
 
 	return nil
 }
 
-func (s *reservationService) reserveSeatsRedis(ctx context.Context, cinemaID uint, seats []models.ReservedSeat, minDist int) error {
-	script, err := scriptloader.LoadReserveScript()
+// generateBookingCode picks a booking code that no reservation has used yet.
+func (s *reservationService) generateBookingCode(ctx context.Context) (string, error) {
+	const maxAttempts = 5
+
+	for i := 0; i < maxAttempts; i++ {
+		code, err := utils.GenerateBookingCode()
+		if err != nil {
+			return "", err
+		}
+
+		exists, err := s.reservationRepo.ExistsByCode(ctx, code)
+		if err != nil {
+			return "", err
+		}
+		if !exists {
+			return code, nil
+		}
+	}
+
+	return "", fmt.Errorf("no unused booking code after %d attempts", maxAttempts)
+}
+
+func (s *reservationService) getShowtime(ctx context.Context, showtimeID uint) (*models.Showtime, error) {
+	showtime, err := s.showtimeRepo.GetByID(ctx, showtimeID)
 	if err != nil {
-		logrus.WithError(err).Error("load script failed")
-		return utils.ErrInternalServer
+		logrus.WithError(err).Error("failed to get showtime by id")
+		return nil, utils.ErrInternalServer
+	}
+	if showtime == nil {
+		return nil, utils.ErrShowtimeNotFound
+	}
+	return showtime, nil
+}
+
+// buildReservedSeats validates the requested positions against the cinema
+// layout of the showtime and its accessibility rules.
+func buildReservedSeats(showtime *models.Showtime, seats []models.SeatRequest, need models.AccessibilityNeed) ([]models.ReservedSeat, error) {
+	cinema := showtime.Cinema
+
+	var reservedSeats []models.ReservedSeat
+	for _, seat := range seats {
+		if !cinema.HasSeat(seat.Row, seat.Column) {
+			return nil, utils.ErrInvalidSeatPosition
+		}
+		reservedSeats = append(reservedSeats, models.ReservedSeat{
+			CinemaID:   cinema.ID,
+			ShowtimeID: showtime.ID,
+			Row:        seat.Row,
+			Column:     seat.Column,
+		})
 	}
 
-	args := []interface{}{minDist}
+	err := checkAccessibleSeats(showtime, models.ReservedSeats(reservedSeats).Seats(), need, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return reservedSeats, nil
+}
+
+// reserveSeatsRedis takes the seats in Redis. With an empty holdID the seats
+// are reserved permanently, otherwise they are held for the caller until
+// expiresAt and join the party once confirmed. The distance rule does not
+// apply towards partySeats.
+func (s *reservationService) reserveSeatsRedis(ctx context.Context, caller *models.Caller, showtime *models.Showtime, seats []models.ReservedSeat, partyID *uint, partySeats []models.Seat, holdID string, expiresAt time.Time) error {
+	cinema := &showtime.Cinema
+	showtimeID := showtime.ID
+	var party uint
+	if partyID != nil {
+		party = *partyID
+	}
+	args := []interface{}{
+		cinema.MinDistance, time.Now().UnixMilli(), holdID, expiresAt.UnixMilli(), showtimeID,
+		caller.UserID, caller.APIKeyID, party,
+		layoutColumns(cinema), string(cinema.DistancePolicy), spanAislesArg(cinema), len(partySeats),
+	}
+	for _, s := range partySeats {
+		args = append(args, fmt.Sprintf("%d:%d", s.Row, s.Column))
+	}
 	for _, s := range seats {
 		args = append(args, fmt.Sprintf("%d:%d", s.Row, s.Column))
 	}
-	key := fmt.Sprintf("cinema:%d:seats", cinemaID)
-
-	result, err := script.Run(ctx, s.redis, []string{key}, args...).Result()
+	keys := append(holdScriptKeys(showtimeID), cinemaLayoutKey(cinema.ID))
+
+	released, err := s.scripts.Run(ctx, scriptloader.Reserve, keys, args...).StringSlice()
+	if err != nil && strings.HasPrefix(err.Error(), "[LAYOUT_MISSING]") {
+		// Redis lost the layout (e.g. after a flush), load it and try again
+		err = storeCinemaLayout(ctx, s.redis, cinema)
+		if err == nil {
+			released, err = s.scripts.Run(ctx, scriptloader.Reserve, keys, args...).StringSlice()
+		}
+	}
 	if err != nil {
 		logrus.WithError(err).Error("seat reservation failed")
 
@@ -171,39 +450,62 @@ func (s *reservationService) reserveSeatsRedis(ctx context.Context, cinemaID uin
 			return utils.ErrSeatsAlreadyReserved
 		} else if strings.HasPrefix(err.Error(), "[MIN_DISTANCE_VIOLATION]") {
 			return utils.ErrMinDistanceViolation
+		} else if strings.HasPrefix(err.Error(), "[NOT_A_SEAT]") {
+			return utils.ErrInvalidSeatPosition
 		}
 
 		return utils.ErrInternalServer
 	}
 
-	if result != "OK" {
-		logrus.Errorf("unexpected result: %v", result)
-		return utils.ErrInternalServer
+	// Expired holds made room for the seats
+	if len(released) > 0 {
+		var freed []models.Seat
+		for _, seatKey := range released {
+			row, column, err := parseSeatKey(seatKey)
+			if err != nil {
+				logrus.WithError(err).Errorf("unexpected released seat: %s", seatKey)
+				continue
+			}
+			freed = append(freed, models.Seat{Row: row, Column: column})
+		}
+		publishSeatEvent(ctx, s.redis, showtimeID, models.SeatEventReleased, freed)
+	}
+
+	eventType := models.SeatEventReserved
+	if holdID != "" {
+		eventType = models.SeatEventHeld
 	}
+	publishSeatEvent(ctx, s.redis, showtimeID, eventType, models.ReservedSeats(seats).Seats())
 
 	return nil
 }
 
-func (s *reservationService) cancelSeatsRedis(ctx context.Context, cinemaID uint, seats []models.ReservedSeat) error {
-	script, err := scriptloader.LoadCancelScript()
-	if err != nil {
-		return fmt.Errorf("load script failed: %w", err)
-	}
-
+// cancelSeatsRedis frees the reserved seats in the seat hash of the
+// showtime and returns the seats it freed. Seats that are held are left
+// alone.
+func cancelSeatsRedis(ctx context.Context, rdb *redis.Client, scripts *scriptloader.Registry, showtimeID uint, seats []models.Seat) ([]models.Seat, error) {
 	args := []interface{}{}
 	for _, s := range seats {
 		args = append(args, fmt.Sprintf("%d:%d", s.Row, s.Column))
 	}
-	key := fmt.Sprintf("cinema:%d:seats", cinemaID)
+	key := showtimeSeatsKey(showtimeID)
 
-	result, err := script.Run(ctx, s.redis, []string{key}, args...).Result()
+	result, err := scripts.Run(ctx, scriptloader.Cancel, []string{key}, args...).StringSlice()
 	if err != nil {
-		return fmt.Errorf("cancel seats failed: %w", err)
+		return nil, fmt.Errorf("cancel seats failed: %w", err)
 	}
 
-	if result != "OK" {
-		return fmt.Errorf("unexpected result: %v", result)
+	var released []models.Seat
+	for _, seatKey := range result {
+		row, column, err := parseSeatKey(seatKey)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected result: %v", result)
+		}
+		released = append(released, models.Seat{Row: row, Column: column})
+	}
+	if len(released) > 0 {
+		publishSeatEvent(ctx, rdb, showtimeID, models.SeatEventReleased, released)
 	}
 
-	return nil
+	return released, nil
 }
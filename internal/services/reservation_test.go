@@ -0,0 +1,143 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"testing"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/utils"
+)
+
+// TestFindPartyNeedsAccessToTheReservation joins a party by its booking code
+// as the callers that may and may not see the reservation behind it.
+func TestFindPartyNeedsAccessToTheReservation(t *testing.T) {
+	owner := uint(1)
+	repo := &fakeReservationRepository{
+		reservations: []models.Reservation{
+			{ID: 7, Code: "ABCD2345", CinemaID: 10, ShowtimeID: 3, UserID: &owner},
+		},
+		reserved: []models.ReservedSeat{
+			{ReservationID: 7, ShowtimeID: 3, Row: 0, Column: 4},
+		},
+	}
+	showtime := &models.Showtime{ID: 3, CinemaID: 10}
+
+	tests := []struct {
+		name    string
+		caller  *models.Caller
+		code    string
+		wantErr error
+	}{
+		{"owner", &models.Caller{UserID: 1, Role: models.UserRoleCustomer}, "abcd-2345", nil},
+		{"staff", &models.Caller{UserID: 2, Role: models.UserRoleStaff}, "ABCD2345", nil},
+		{"anonymous", nil, "ABCD2345", utils.ErrUnauthorized},
+		{"another customer", &models.Caller{UserID: 5, Role: models.UserRoleCustomer}, "ABCD2345", utils.ErrPartyNotFound},
+		{"partner", &models.Caller{APIKeyID: 1, Role: models.UserRolePartner}, "ABCD2345", utils.ErrPartyNotFound},
+		{"unknown code", &models.Caller{UserID: 1, Role: models.UserRoleCustomer}, "ZZZZ2345", utils.ErrPartyNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			partyID, seats, err := findParty(context.Background(), repo, tt.caller, showtime, tt.code)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if partyID == nil || *partyID != 7 {
+				t.Errorf("party = %v, want 7", partyID)
+			}
+			if len(seats) != 1 || seats[0] != (models.Seat{Row: 0, Column: 4}) {
+				t.Errorf("party seats = %v, want [0:4]", seats)
+			}
+		})
+	}
+}
+
+// TestGenerateBookingCode draws codes while the first ones are taken.
+func TestGenerateBookingCode(t *testing.T) {
+	format := regexp.MustCompile(`^[A-HJKMNP-Z2-9]{8}$`)
+
+	tests := []struct {
+		name       string
+		collisions int
+		wantChecks int
+		wantErr    bool
+	}{
+		{"free at once", 0, 1, false},
+		{"retried after collisions", 2, 3, false},
+		{"gives up", 5, 5, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeReservationRepository{collisions: tt.collisions}
+			service := &reservationService{reservationRepo: repo}
+
+			code, err := service.generateBookingCode(context.Background())
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, want error %v", err, tt.wantErr)
+			}
+			if len(repo.checkedCodes) != tt.wantChecks {
+				t.Errorf("checked %d codes, want %d", len(repo.checkedCodes), tt.wantChecks)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !format.MatchString(code) {
+				t.Errorf("code %q has the wrong format", code)
+			}
+			if code != repo.checkedCodes[len(repo.checkedCodes)-1] {
+				t.Errorf("code %q is not the last one checked, %v", code, repo.checkedCodes)
+			}
+		})
+	}
+}
+
+// TestGetReservationByCode looks a reservation up by its code as callers
+// that may and may not see it.
+func TestGetReservationByCode(t *testing.T) {
+	owner, key := uint(1), uint(3)
+	service := &reservationService{reservationRepo: &fakeReservationRepository{
+		reservations: []models.Reservation{
+			{ID: 7, Code: "ABCD2345", CinemaID: 10, UserID: &owner},
+			{ID: 8, Code: "WXYZ6789", CinemaID: 10, APIKeyID: &key},
+		},
+	}}
+
+	tests := []struct {
+		name   string
+		caller *models.Caller
+		code   string
+		wantID uint // 0 when not found
+	}{
+		{"owner", &models.Caller{UserID: 1, Role: models.UserRoleCustomer}, "ABCD2345", 7},
+		{"owner reading the code out", &models.Caller{UserID: 1, Role: models.UserRoleCustomer}, "abcd-2345", 7},
+		{"another customer", &models.Caller{UserID: 5, Role: models.UserRoleCustomer}, "ABCD2345", 0},
+		{"staff", &models.Caller{UserID: 2, Role: models.UserRoleStaff}, "ABCD2345", 7},
+		{"manager of the cinema", &models.Caller{UserID: 4, Role: models.UserRoleManager, CinemaIDs: []uint{10}}, "ABCD2345", 7},
+		{"manager of another cinema", &models.Caller{UserID: 4, Role: models.UserRoleManager, CinemaIDs: []uint{11}}, "ABCD2345", 0},
+		{"partner of the key", &models.Caller{APIKeyID: 3, Role: models.UserRolePartner}, "WXYZ6789", 8},
+		{"partner of another key", &models.Caller{APIKeyID: 4, Role: models.UserRolePartner}, "WXYZ6789", 0},
+		{"customer with the key's ID", &models.Caller{UserID: 3, Role: models.UserRoleCustomer}, "WXYZ6789", 0},
+		{"unknown code", &models.Caller{UserID: 2, Role: models.UserRoleStaff}, "ZZZZ2345", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reservation, err := service.GetReservationByCode(context.Background(), tt.caller, tt.code)
+			if tt.wantID == 0 {
+				if !errors.Is(err, utils.ErrReservationNotFound) {
+					t.Errorf("err = %v, want %v", err, utils.ErrReservationNotFound)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if reservation.ID != tt.wantID {
+				t.Errorf("reservation = %d, want %d", reservation.ID, tt.wantID)
+			}
+		})
+	}
+}
@@ -0,0 +1,156 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	scriptloader "cinema-reservation/internal/scripts"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// testRedis connects to the Redis server in TEST_REDIS_URL, skipping the
+// test without one. Tests only touch keys of random showtime IDs.
+func testRedis(tb testing.TB) *redis.Client {
+	tb.Helper()
+
+	redisURL := os.Getenv("TEST_REDIS_URL")
+	if redisURL == "" {
+		tb.Skip("TEST_REDIS_URL is not set")
+	}
+	opt, err := redis.ParseURL(redisURL)
+	if err != nil {
+		tb.Fatalf("invalid TEST_REDIS_URL: %v", err)
+	}
+	rdb := redis.NewClient(opt)
+	tb.Cleanup(func() { rdb.Close() })
+	return rdb
+}
+
+func TestReserveScriptSeparateGroupsKeepDistance(t *testing.T) {
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	showtimeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	keys := append(holdScriptKeys(showtimeID), cinemaLayoutKey(showtimeID))
+	defer rdb.Del(ctx, keys[0], keys[4])
+
+	err = rdb.Set(ctx, keys[4], "SSASSSS", 0).Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		spanAisles string
+		party      []string
+		seats      []string
+		wantErr    string
+	}{
+		{"adjacent seats", "0", nil, []string{"0:3", "0:4"}, ""},
+		{"seat listed twice", "0", nil, []string{"0:3", "0:3"}, ""},
+		{"separate groups too close", "0", nil, []string{"0:3", "0:5"}, "[MIN_DISTANCE_VIOLATION]"},
+		{"separate groups far enough", "0", nil, []string{"0:3", "0:6"}, ""},
+		{"joined through the party", "0", []string{"0:4"}, []string{"0:3", "0:5"}, ""},
+		{"across an aisle", "1", nil, []string{"0:1", "0:3"}, ""},
+		{"across an aisle without spanning", "0", nil, []string{"0:1", "0:3"}, "[MIN_DISTANCE_VIOLATION]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rdb.Del(ctx, keys[0])
+
+			args := []interface{}{3, time.Now().UnixMilli(), "", 0, showtimeID, 0, 0, 0, 7, "manhattan", tt.spanAisles, len(tt.party)}
+			for _, seat := range tt.party {
+				args = append(args, seat)
+			}
+			for _, seat := range tt.seats {
+				args = append(args, seat)
+			}
+
+			err := scripts.Run(ctx, scriptloader.Reserve, keys, args...).Err()
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("got %v, want the seats reserved", err)
+			case tt.wantErr != "" && (err == nil || !strings.HasPrefix(err.Error(), tt.wantErr)):
+				t.Errorf("got %v, want %s", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// BenchmarkReserveScript compares the distance check of reserve.lua with the
+// previous version, which scanned every taken seat of the hall, on a 100x100
+// hall filled in a checkerboard. Run it with:
+//
+//	TEST_REDIS_URL=redis://localhost:6379/15 go test -run '^$' -bench ReserveScript ./internal/services/
+func BenchmarkReserveScript(b *testing.B) {
+	rdb := testRedis(b)
+
+	data, err := os.ReadFile("testdata/reserve_hkeys.lua")
+	if err != nil {
+		b.Fatal(err)
+	}
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	const size, minDistance = 100, 2
+	free := "50:50"
+
+	ctx := context.Background()
+	showtimeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	keys := append(holdScriptKeys(showtimeID), cinemaLayoutKey(showtimeID))
+	defer rdb.Del(ctx, keys[0])
+
+	// Every other seat is taken, the closest a full hall gets at distance 2
+	fill := make([]interface{}, 0, size*size)
+	for r := 0; r < size; r++ {
+		for c := (r % 2); c < size; c += 2 {
+			if seat := fmt.Sprintf("%d:%d", r, c); seat != free {
+				fill = append(fill, seat, "1")
+			}
+		}
+	}
+	err = rdb.HSet(ctx, keys[0], fill...).Err()
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	hkeys := redis.NewScript(string(data))
+	versions := []struct {
+		name string
+		run  func() error
+	}{
+		{"hkeys", func() error {
+			return hkeys.Run(ctx, rdb, keys, minDistance, 0, "", 0, showtimeID, 0, "manhattan", 0, free).Err()
+		}},
+		{"neighborhood", func() error {
+			return scripts.Run(ctx, scriptloader.Reserve, keys, minDistance, 0, "", 0, showtimeID, 0, 0, 0, 0, "manhattan", "0", 0, free).Err()
+		}},
+	}
+
+	for _, v := range versions {
+		b.Run(v.name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				err := v.run()
+				if err != nil {
+					b.Fatal(err)
+				}
+
+				b.StopTimer()
+				rdb.HDel(ctx, keys[0], free)
+				b.StartTimer()
+			}
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"cinema-reservation/internal/models"
+
+	"github.com/go-redis/redis/v8"
+)
+
+// seatSnapshot is the occupancy of a showtime at one point in time. It is
+// shared between readers and must not be modified.
+type seatSnapshot struct {
+	// occupied maps the taken seats to their value ("1" or "hold:{holdID}")
+	occupied map[string]string
+	reserved []string
+	heat     [][]bool
+}
+
+// seatCache keeps the occupancy and heatmap of the showtimes being read, so
+// availability reads do not fetch the seat hash and rebuild the heatmap every
+// time. An entry is reloaded once a seat event of its showtime arrives; the
+// TTL only guards against a lost event.
+type seatCache struct {
+	events SeatEventHub
+	ttl    time.Duration
+	load   func(ctx context.Context, showtimeID uint) (map[string]string, error)
+
+	mu      sync.Mutex
+	entries map[uint]*seatCacheEntry
+}
+
+type seatCacheEntry struct {
+	snapshot    *seatSnapshot
+	loadedAt    time.Time
+	lastRead    time.Time
+	changed     <-chan struct{}
+	unsubscribe func()
+}
+
+func newSeatCache(rdb *redis.Client, events SeatEventHub, ttl time.Duration) *seatCache {
+	return &seatCache{
+		events: events,
+		ttl:    ttl,
+		load: func(ctx context.Context, showtimeID uint) (map[string]string, error) {
+			data, err := rdb.HGetAll(ctx, showtimeSeatsKey(showtimeID)).Result()
+			if err != nil {
+				return nil, fmt.Errorf("failed to fetch reserved seats from redis hash: %w", err)
+			}
+			return data, nil
+		},
+		entries: make(map[uint]*seatCacheEntry),
+	}
+}
+
+// get returns the occupancy of the showtime, loading it from Redis when it is
+// not cached, has changed or is older than the TTL.
+func (c *seatCache) get(ctx context.Context, cinema *models.Cinema, showtimeID uint) (*seatSnapshot, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	c.evictIdle(now)
+	entry := c.entries[showtimeID]
+	if entry == nil {
+		// Subscribe before loading so no change slips in between
+		changed, unsubscribe := c.events.Subscribe(showtimeID)
+		entry = &seatCacheEntry{changed: changed, unsubscribe: unsubscribe}
+		c.entries[showtimeID] = entry
+	}
+	entry.lastRead = now
+
+	select {
+	case <-entry.changed:
+		entry.snapshot = nil
+	default:
+	}
+	if entry.snapshot != nil && now.Sub(entry.loadedAt) < c.ttl {
+		snapshot := entry.snapshot
+		c.mu.Unlock()
+		return snapshot, nil
+	}
+	c.mu.Unlock()
+
+	snapshot, err := c.fresh(ctx, cinema, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+
+	c.mu.Lock()
+	// A change during the load left a signal behind, so the next read
+	// reloads again
+	if c.entries[showtimeID] == entry {
+		entry.snapshot = snapshot
+		entry.loadedAt = now
+	}
+	c.mu.Unlock()
+
+	return snapshot, nil
+}
+
+// fresh loads the occupancy of the showtime from Redis, bypassing the cache.
+// Seat map watchers use it: a seat event may reach them before it reaches
+// the cache, which would then still serve the occupancy before the change.
+func (c *seatCache) fresh(ctx context.Context, cinema *models.Cinema, showtimeID uint) (*seatSnapshot, error) {
+	occupied, err := c.load(ctx, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := make([]string, 0, len(occupied))
+	for seat := range occupied {
+		reserved = append(reserved, seat)
+	}
+	return &seatSnapshot{
+		occupied: occupied,
+		reserved: reserved,
+		heat:     buildHeatmap(cinema, reserved),
+	}, nil
+}
+
+// evictIdle drops the showtimes nobody has read for a TTL. Must be called
+// with c.mu held.
+func (c *seatCache) evictIdle(now time.Time) {
+	for showtimeID, entry := range c.entries {
+		if now.Sub(entry.lastRead) >= c.ttl {
+			entry.unsubscribe()
+			delete(c.entries, showtimeID)
+		}
+	}
+}
@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cinema-reservation/internal/models"
+)
+
+// newTestSeatCache returns a cache over a fake seat hash and a counter of
+// the loads from it.
+func newTestSeatCache(ttl time.Duration, occupied map[string]string) (*seatCache, *seatEventHub, *int) {
+	hub := NewSeatEventHub(nil).(*seatEventHub)
+	loads := 0
+	cache := &seatCache{
+		events: hub,
+		ttl:    ttl,
+		load: func(ctx context.Context, showtimeID uint) (map[string]string, error) {
+			loads++
+			copied := make(map[string]string, len(occupied))
+			for seat, value := range occupied {
+				copied[seat] = value
+			}
+			return copied, nil
+		},
+		entries: make(map[uint]*seatCacheEntry),
+	}
+	return cache, hub, &loads
+}
+
+func TestSeatCacheReloadsOnSeatEvent(t *testing.T) {
+	ctx := context.Background()
+	cinema := &models.Cinema{Rows: 1, Columns: 5, MinDistance: 2}
+	occupied := map[string]string{"0:0": "1"}
+	cache, hub, loads := newTestSeatCache(time.Minute, occupied)
+
+	for i := 0; i < 3; i++ {
+		if _, err := cache.get(ctx, cinema, 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if *loads != 1 {
+		t.Fatalf("loaded %d times for repeated reads, want 1", *loads)
+	}
+
+	occupied["0:4"] = "1"
+	hub.notify(1)
+
+	seats, err := cache.get(ctx, cinema, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *loads != 2 {
+		t.Fatalf("loaded %d times after a seat event, want 2", *loads)
+	}
+	if !seats.heat[0][3] || seats.heat[0][2] {
+		t.Errorf("heat = %v, want the new seat and its neighbor blocked", seats.heat)
+	}
+
+	// Events of other showtimes do not invalidate the entry
+	hub.notify(2)
+	if _, err := cache.get(ctx, cinema, 1); err != nil {
+		t.Fatal(err)
+	}
+	if *loads != 2 {
+		t.Fatalf("loaded %d times after another showtime changed, want 2", *loads)
+	}
+}
+
+func TestSeatCacheExpires(t *testing.T) {
+	ctx := context.Background()
+	cinema := &models.Cinema{Rows: 1, Columns: 3}
+	cache, hub, loads := newTestSeatCache(10*time.Millisecond, nil)
+
+	if _, err := cache.get(ctx, cinema, 1); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(20 * time.Millisecond)
+	if _, err := cache.get(ctx, cinema, 1); err != nil {
+		t.Fatal(err)
+	}
+	if *loads != 2 {
+		t.Fatalf("loaded %d times across the TTL, want 2", *loads)
+	}
+
+	// Idle showtimes are dropped along with their subscription
+	time.Sleep(20 * time.Millisecond)
+	if _, err := cache.get(ctx, cinema, 2); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := cache.entries[1]; ok {
+		t.Error("idle showtime is still cached")
+	}
+	if _, ok := hub.subscribers[1]; ok {
+		t.Error("idle showtime is still subscribed")
+	}
+}
+
+// TestWatchSeatMapSeesChangesTheCacheMissed delivers a seat event to a
+// watcher while the cache still holds the occupancy before the change.
+func TestWatchSeatMapSeesChangesTheCacheMissed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	cinema := &models.Cinema{ID: 1, Slug: "downtown", Rows: 1, Columns: 5}
+	showtime := &models.Showtime{ID: 7, CinemaID: cinema.ID}
+	occupied := map[string]string{}
+	cache, _, _ := newTestSeatCache(time.Minute, occupied)
+	// The watcher hears the event, the cache does not
+	watcherEvents := NewSeatEventHub(nil).(*seatEventHub)
+
+	service := &cinemaService{
+		cinemaRepo:    &fakeCinemaRepository{cinemas: map[string]*models.Cinema{cinema.Slug: cinema}},
+		showtimeRepo:  &fakeShowtimeRepository{showtimes: map[uint]*models.Showtime{showtime.ID: showtime}},
+		seatPriceRepo: &fakeSeatPriceRepository{},
+		seatEvents:    watcherEvents,
+		seats:         cache,
+	}
+
+	// Warm the cache with the empty hall
+	if _, err := cache.get(ctx, cinema, showtime.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	seatMap, deltas, err := service.WatchSeatMap(ctx, cinema.Slug, showtime.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if seatMap.Seats[0][2] != models.SeatAvailable {
+		t.Fatalf("seat 0:2 = %s before the change", seatMap.Seats[0][2])
+	}
+
+	occupied["0:2"] = "1"
+	watcherEvents.notify(showtime.ID)
+
+	select {
+	case delta := <-deltas:
+		found := false
+		for _, change := range delta.Seats {
+			if change.Row == 0 && change.Column == 2 && change.State == models.SeatReserved {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("delta %+v does not reserve seat 0:2", delta.Seats)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("no delta after the seat event")
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"cinema-reservation/internal/models"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+// publishSeatEvent announces a seat change to every server instance. Seat
+// changes are already committed at this point, so a failure is only logged.
+func publishSeatEvent(ctx context.Context, rdb *redis.Client, showtimeID uint, eventType string, seats []models.Seat) {
+	payload, err := json.Marshal(models.SeatEvent{
+		ShowtimeID: showtimeID,
+		Type:       eventType,
+		Seats:      seats,
+	})
+	if err != nil {
+		logrus.WithError(err).Warn("failed to encode seat event")
+		return
+	}
+
+	err = rdb.Publish(ctx, showtimeSeatEventsChannel(showtimeID), payload).Err()
+	if err != nil {
+		logrus.WithError(err).WithField("showtime_id", showtimeID).Warn("failed to publish seat event")
+	}
+}
+
+// seatEventHub holds a single pattern subscription per server instance and
+// fans the notifications out to the local subscribers of each showtime.
+type seatEventHub struct {
+	redis *redis.Client
+
+	mu          sync.Mutex
+	subscribers map[uint]map[chan struct{}]struct{}
+}
+
+func NewSeatEventHub(redis *redis.Client) SeatEventHub {
+	return &seatEventHub{
+		redis:       redis,
+		subscribers: make(map[uint]map[chan struct{}]struct{}),
+	}
+}
+
+// Run listens for seat events until ctx is done.
+func (h *seatEventHub) Run(ctx context.Context) {
+	pubsub := h.redis.PSubscribe(ctx, seatEventsPattern)
+	defer pubsub.Close()
+
+	ch := pubsub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+			showtimeID, ok := parseSeatEventsChannel(msg.Channel)
+			if ok {
+				h.notify(showtimeID)
+			}
+		}
+	}
+}
+
+// Subscribe returns a channel that receives a signal whenever the seats of
+// the showtime change, and a function to stop the subscription. Signals are
+// coalesced, so a slow subscriber only sees that something changed.
+func (h *seatEventHub) Subscribe(showtimeID uint) (<-chan struct{}, func()) {
+	ch := make(chan struct{}, 1)
+
+	h.mu.Lock()
+	if h.subscribers[showtimeID] == nil {
+		h.subscribers[showtimeID] = make(map[chan struct{}]struct{})
+	}
+	h.subscribers[showtimeID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	unsubscribe := func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		delete(h.subscribers[showtimeID], ch)
+		if len(h.subscribers[showtimeID]) == 0 {
+			delete(h.subscribers, showtimeID)
+		}
+	}
+
+	return ch, unsubscribe
+}
+
+func (h *seatEventHub) notify(showtimeID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for ch := range h.subscribers[showtimeID] {
+		select {
+		case ch <- struct{}{}:
+		default:
+			// A signal is already pending
+		}
+	}
+}
+
+// parseSeatEventsChannel extracts the showtime ID from
+// "showtime:{id}:seat_events".
+func parseSeatEventsChannel(channel string) (uint, bool) {
+	parts := strings.Split(channel, ":")
+	if len(parts) != 3 {
+		return 0, false
+	}
+	id, err := strconv.ParseUint(parts[1], 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(id), true
+}
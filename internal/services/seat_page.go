@@ -0,0 +1,86 @@
+package services
+
+import (
+	"encoding/base64"
+	"strconv"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/utils"
+)
+
+// paginate returns the page of items starting at offset and the cursor of
+// the next page. A limit of 0 returns every remaining item.
+func paginate[T any](items []T, offset, limit int) ([]T, string) {
+	if offset >= len(items) {
+		return []T{}, ""
+	}
+	items = items[offset:]
+	if limit == 0 || limit >= len(items) {
+		return items, ""
+	}
+	return items[:limit], encodeSeatCursor(offset + limit)
+}
+
+// Cursors are opaque to clients but only hold the offset of the next page,
+// so a page can shift when seats are taken in between.
+func encodeSeatCursor(offset int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.Itoa(offset)))
+}
+
+func decodeSeatCursor(cursor string) (int, error) {
+	if cursor == "" {
+		return 0, nil
+	}
+	data, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, utils.ErrInvalidCursor
+	}
+	offset, err := strconv.Atoi(string(data))
+	if err != nil || offset < 0 {
+		return 0, utils.ErrInvalidCursor
+	}
+	return offset, nil
+}
+
+func blockHasRow(block []models.Seat, row int) bool {
+	for _, seat := range block {
+		if seat.Row == row {
+			return true
+		}
+	}
+	return false
+}
+
+// buildSeatRuns merges row blocks, as listed by FindSafeBlocks, into runs.
+// A block joins the run of the previous block when it starts on the seat
+// after the previous block's start.
+func buildSeatRuns(cinema *models.Cinema, blocks [][]models.Seat) []models.SeatRun {
+	var runs []models.SeatRun
+	var prevStart models.Seat
+	for i, block := range blocks {
+		start, end := block[0], block[len(block)-1]
+
+		if i > 0 && start.Row == prevStart.Row && start.Column == nextSeatColumn(cinema, prevStart.Row, prevStart.Column) {
+			run := &runs[len(runs)-1]
+			run.Length = end.Column - run.StartColumn + 1
+		} else {
+			runs = append(runs, models.SeatRun{
+				Row:         start.Row,
+				StartColumn: start.Column,
+				Length:      end.Column - start.Column + 1,
+			})
+		}
+		prevStart = start
+	}
+	return runs
+}
+
+// nextSeatColumn is the column a row block continues in after the column,
+// skipping aisles when the cinema lets blocks span them.
+func nextSeatColumn(cinema *models.Cinema, row, column int) int {
+	column++
+	for cinema.BlocksSpanAisles && column < cinema.Columns && cinema.Layout.Cell(row, column) == models.LayoutAisle {
+		column++
+	}
+	return column
+}
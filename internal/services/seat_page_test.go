@@ -0,0 +1,86 @@
+package services
+
+import (
+	"reflect"
+	"testing"
+
+	"cinema-reservation/internal/models"
+)
+
+func TestBuildSeatRuns(t *testing.T) {
+	tests := []struct {
+		name      string
+		cinema    *models.Cinema
+		taken     []string
+		groupSize int
+		want      []models.SeatRun
+	}{
+		{
+			name:      "single seats",
+			cinema:    &models.Cinema{Rows: 2, Columns: 5, MinDistance: 1},
+			taken:     []string{"0:2"},
+			groupSize: 1,
+			want:      []models.SeatRun{{Row: 0, StartColumn: 0, Length: 2}, {Row: 0, StartColumn: 3, Length: 2}, {Row: 1, StartColumn: 0, Length: 5}},
+		},
+		{
+			name:      "pairs",
+			cinema:    &models.Cinema{Rows: 1, Columns: 6, MinDistance: 1},
+			taken:     []string{"0:2"},
+			groupSize: 2,
+			want:      []models.SeatRun{{Row: 0, StartColumn: 0, Length: 2}, {Row: 0, StartColumn: 3, Length: 3}},
+		},
+		{
+			name:      "split by aisle",
+			cinema:    &models.Cinema{Rows: 1, Columns: 5, Layout: models.Layout{"SSASS"}},
+			groupSize: 1,
+			want:      []models.SeatRun{{Row: 0, StartColumn: 0, Length: 2}, {Row: 0, StartColumn: 3, Length: 2}},
+		},
+		{
+			name:      "spanning aisle",
+			cinema:    &models.Cinema{Rows: 1, Columns: 5, Layout: models.Layout{"SSASS"}, BlocksSpanAisles: true},
+			groupSize: 2,
+			want:      []models.SeatRun{{Row: 0, StartColumn: 0, Length: 5}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			heat := buildHeatmap(tt.cinema, tt.taken)
+			got := buildSeatRuns(tt.cinema, FindSafeBlocks(tt.cinema, heat, tt.groupSize))
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("buildSeatRuns() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPaginate(t *testing.T) {
+	items := []int{1, 2, 3, 4, 5}
+
+	var pages [][]int
+	offset := 0
+	for {
+		page, next := paginate(items, offset, 2)
+		pages = append(pages, page)
+		if next == "" {
+			break
+		}
+		var err error
+		offset, err = decodeSeatCursor(next)
+		if err != nil {
+			t.Fatalf("decodeSeatCursor(%q): %v", next, err)
+		}
+	}
+
+	want := [][]int{{1, 2}, {3, 4}, {5}}
+	if !reflect.DeepEqual(pages, want) {
+		t.Errorf("pages = %v, want %v", pages, want)
+	}
+
+	if page, next := paginate(items, 0, 0); len(page) != 5 || next != "" {
+		t.Errorf("paginate without limit = %v, %q, want every item", page, next)
+	}
+	if _, err := decodeSeatCursor("not a cursor"); err == nil {
+		t.Error("decodeSeatCursor accepted a malformed cursor")
+	}
+}
@@ -0,0 +1,235 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/repositories"
+	scriptloader "cinema-reservation/internal/scripts"
+	"cinema-reservation/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	seatReleaseBatch       = 50
+	seatReleaseMaxAttempts = 8
+	seatReleaseBaseBackoff = 5 * time.Second
+	seatReleaseMaxBackoff  = 10 * time.Minute
+	// seatReleaseLease keeps a claimed release away from other workers while
+	// it is being processed.
+	seatReleaseLease = time.Minute
+)
+
+type seatReleaseService struct {
+	seatReleaseRepo repositories.SeatReleaseRepository
+	reservationRepo repositories.ReservationRepository
+	redis           *redis.Client
+	scripts         *scriptloader.Registry
+}
+
+func NewSeatReleaseService(
+	seatReleaseRepo repositories.SeatReleaseRepository,
+	reservationRepo repositories.ReservationRepository,
+	redis *redis.Client,
+	scripts *scriptloader.Registry,
+) SeatReleaseService {
+	return &seatReleaseService{
+		seatReleaseRepo: seatReleaseRepo,
+		reservationRepo: reservationRepo,
+		redis:           redis,
+		scripts:         scripts,
+	}
+}
+
+// Enqueue records seats whose release in Redis failed so the worker can retry
+// it. If even that fails the seats stay blocked until the reconciler repairs
+// them.
+func (s *seatReleaseService) Enqueue(ctx context.Context, showtimeID uint, seats []models.Seat, reason string, cause error) {
+	release := &models.SeatRelease{
+		ShowtimeID:    showtimeID,
+		Seats:         seats,
+		Reason:        reason,
+		Status:        models.SeatReleasePending,
+		NextAttemptAt: time.Now().Add(seatReleaseBaseBackoff),
+		LastError:     cause.Error(),
+	}
+
+	// The request context may already be canceled, the release must not be lost
+	err := s.seatReleaseRepo.Create(context.WithoutCancel(ctx), release)
+	if err != nil {
+		logrus.WithFields(logrus.Fields{
+			"showtime_id": showtimeID,
+			"seats":       seats,
+			"reason":      reason,
+			"error":       err.Error(),
+		}).Error("CRITICAL: Failed to queue seat release - left for the reconciler")
+	}
+}
+
+// ProcessDue retries every due release once and returns how many succeeded.
+func (s *seatReleaseService) ProcessDue(ctx context.Context) (int, error) {
+	now := time.Now()
+	releases, err := s.seatReleaseRepo.ClaimDue(ctx, now, now.Add(seatReleaseLease), seatReleaseBatch)
+	if err != nil {
+		return 0, err
+	}
+
+	succeeded := 0
+	for i := range releases {
+		release := &releases[i]
+		err := s.release(ctx, release)
+		if err == nil {
+			err = s.seatReleaseRepo.Delete(ctx, release.ID)
+			if err != nil {
+				return succeeded, err
+			}
+			succeeded++
+			continue
+		}
+
+		release.Attempts++
+		release.LastError = err.Error()
+		if release.Attempts >= seatReleaseMaxAttempts {
+			release.Status = models.SeatReleaseDead
+			logrus.WithFields(logrus.Fields{
+				"seat_release_id": release.ID,
+				"showtime_id":     release.ShowtimeID,
+				"seats":           release.Seats,
+				"attempts":        release.Attempts,
+				"error":           release.LastError,
+			}).Error("CRITICAL: Seat release exhausted its retries - admin action required")
+		} else {
+			release.NextAttemptAt = time.Now().Add(seatReleaseBackoff(release.Attempts))
+		}
+
+		err = s.seatReleaseRepo.Update(ctx, release)
+		if err != nil {
+			return succeeded, err
+		}
+	}
+
+	return succeeded, nil
+}
+
+// StartWorker runs ProcessDue every interval until ctx is done.
+func (s *seatReleaseService) StartWorker(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			succeeded, err := s.ProcessDue(ctx)
+			if err != nil {
+				logrus.WithError(err).Error("failed to process seat releases")
+				continue
+			}
+			if succeeded > 0 {
+				logrus.Infof("Released seats of %d queued seat releases", succeeded)
+			}
+		}
+	}
+}
+
+func (s *seatReleaseService) ListReleases(ctx context.Context, status string) ([]models.SeatRelease, error) {
+	if status != "" && status != models.SeatReleasePending && status != models.SeatReleaseDead {
+		return nil, utils.ErrInvalidInput
+	}
+
+	releases, err := s.seatReleaseRepo.List(ctx, status)
+	if err != nil {
+		logrus.WithError(err).Error("failed to list seat releases")
+		return nil, utils.ErrInternalServer
+	}
+	return releases, nil
+}
+
+// RetryRelease puts a release back into the queue with a fresh set of attempts
+// and makes it due immediately.
+func (s *seatReleaseService) RetryRelease(ctx context.Context, id uint) (*models.SeatRelease, error) {
+	release, err := s.getRelease(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	release.Status = models.SeatReleasePending
+	release.Attempts = 0
+	release.NextAttemptAt = time.Now()
+
+	err = s.seatReleaseRepo.Update(ctx, release)
+	if err != nil {
+		logrus.WithError(err).Error("failed to update seat release")
+		return nil, utils.ErrInternalServer
+	}
+	return release, nil
+}
+
+func (s *seatReleaseService) DiscardRelease(ctx context.Context, id uint) error {
+	release, err := s.getRelease(ctx, id)
+	if err != nil {
+		return err
+	}
+
+	err = s.seatReleaseRepo.Delete(ctx, release.ID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to delete seat release")
+		return utils.ErrInternalServer
+	}
+	return nil
+}
+
+func (s *seatReleaseService) getRelease(ctx context.Context, id uint) (*models.SeatRelease, error) {
+	release, err := s.seatReleaseRepo.GetByID(ctx, id)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get seat release by id")
+		return nil, utils.ErrInternalServer
+	}
+	if release == nil {
+		return nil, utils.ErrSeatReleaseNotFound
+	}
+	return release, nil
+}
+
+// release frees the seats in Redis, skipping any seat that has been sold
+// again in the meantime so a valid reservation is never wiped.
+func (s *seatReleaseService) release(ctx context.Context, release *models.SeatRelease) error {
+	active, err := s.reservationRepo.FindReservedSeats(ctx, release.ShowtimeID, release.Seats)
+	if err != nil {
+		return err
+	}
+
+	sold := make(map[models.Seat]bool, len(active))
+	for _, seat := range active {
+		sold[models.Seat{Row: seat.Row, Column: seat.Column}] = true
+	}
+
+	var seats []models.Seat
+	for _, seat := range release.Seats {
+		if !sold[seat] {
+			seats = append(seats, seat)
+		}
+	}
+	if len(seats) == 0 {
+		return nil
+	}
+
+	_, err = cancelSeatsRedis(ctx, s.redis, s.scripts, release.ShowtimeID, seats)
+	return err
+}
+
+// seatReleaseBackoff doubles the delay with every failed attempt.
+func seatReleaseBackoff(attempts int) time.Duration {
+	backoff := seatReleaseBaseBackoff
+	for i := 1; i < attempts && backoff < seatReleaseMaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > seatReleaseMaxBackoff {
+		backoff = seatReleaseMaxBackoff
+	}
+	return backoff
+}
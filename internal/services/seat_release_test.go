@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"cinema-reservation/internal/models"
+	scriptloader "cinema-reservation/internal/scripts"
+)
+
+// TestSeatReleaseKeepsSeatsTakenAgain retries a release after one of its
+// seats was held and another one sold by a new customer.
+func TestSeatReleaseKeepsSeatsTakenAgain(t *testing.T) {
+	rdb := testRedis(t)
+	scripts, err := scriptloader.NewRegistry(rdb)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	showtimeID := uint(time.Now().UnixNano() % 1_000_000_000)
+	key := showtimeSeatsKey(showtimeID)
+	t.Cleanup(func() { rdb.Del(ctx, key) })
+
+	err = rdb.HSet(ctx, key, "0:0", "1", "0:1", "hold:abc", "0:2", "1").Err()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	service := &seatReleaseService{
+		reservationRepo: &fakeReservationRepository{reserved: []models.ReservedSeat{
+			{ShowtimeID: showtimeID, Row: 0, Column: 2},
+		}},
+		redis:   rdb,
+		scripts: scripts,
+	}
+	err = service.release(ctx, &models.SeatRelease{
+		ShowtimeID: showtimeID,
+		Seats:      []models.Seat{{Row: 0, Column: 0}, {Row: 0, Column: 1}, {Row: 0, Column: 2}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	seats, err := rdb.HGetAll(ctx, key).Result()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"0:1": "hold:abc", "0:2": "1"}
+	if len(seats) != len(want) || seats["0:1"] != want["0:1"] || seats["0:2"] != want["0:2"] {
+		t.Errorf("seats = %v, want %v", seats, want)
+	}
+}
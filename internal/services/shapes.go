@@ -0,0 +1,226 @@
+package services
+
+import (
+	"sort"
+
+	"cinema-reservation/internal/models"
+)
+
+const (
+	// maxShapedBlocks caps the blocks returned for the rectangle and
+	// any-connected shapes, which can be far more than a client can show.
+	maxShapedBlocks = 200
+	// maxShapedGroupSize bounds the search for connected blocks, whose number
+	// grows exponentially with the group size.
+	maxShapedGroupSize = 8
+	// maxShapedCandidates caps the seat sets the rectangle and any-connected
+	// searches try, so a filter that rejects nearly every block cannot make
+	// them walk the whole hall.
+	maxShapedCandidates = 200000
+)
+
+// findShapedBlocks lists the blocks of groupSize safe seats in the shape that
+// accept keeps. The rectangle and any-connected searches only start blocks in
+// startRows (every row when nil). Only row blocks are returned without a cap.
+func findShapedBlocks(cinema *models.Cinema, heat [][]bool, groupSize int, shape models.SeatBlockShape, startRows []bool, accept func([]models.Seat) bool) [][]models.Seat {
+	switch shape {
+	case models.BlockShapeRectangle:
+		return findRectangleBlocks(heat, groupSize, startRows, accept)
+	case models.BlockShapeAnyConnected:
+		return findConnectedBlocks(cinema, heat, groupSize, startRows, accept)
+	}
+
+	var blocks [][]models.Seat
+	for _, block := range FindSafeBlocks(cinema, heat, groupSize) {
+		if accept(block) {
+			blocks = append(blocks, block)
+		}
+	}
+	return blocks
+}
+
+// blockStartRows narrows startRows to the rows a block of groupSize seats
+// with a seat in one of the rows can start in. A block spans at most
+// groupSize rows from the row of its first seat.
+func blockStartRows(startRows []bool, rows, groupSize int, required []int) []bool {
+	narrowed := make([]bool, rows)
+	for _, row := range required {
+		for r := max(row-groupSize+1, 0); r <= row && r < rows; r++ {
+			narrowed[r] = startRows == nil || startRows[r]
+		}
+	}
+	return narrowed
+}
+
+// findRectangleBlocks lists the rectangles of safe seats with groupSize
+// seats, the most square ones first. Rectangles never span an aisle.
+func findRectangleBlocks(heat [][]bool, groupSize int, startRows []bool, accept func([]models.Seat) bool) [][]models.Seat {
+	rows := len(heat)
+	cols := len(heat[0])
+	var results [][]models.Seat
+	candidates := 0
+
+	var heights []int
+	for height := 1; height <= groupSize; height++ {
+		if groupSize%height == 0 {
+			heights = append(heights, height)
+		}
+	}
+	sort.SliceStable(heights, func(i, j int) bool {
+		return rectangleSkew(heights[i], groupSize) < rectangleSkew(heights[j], groupSize)
+	})
+
+	for _, height := range heights {
+		width := groupSize / height
+
+		for r := 0; r+height <= rows; r++ {
+			if startRows != nil && !startRows[r] {
+				continue
+			}
+			for c := 0; c+width <= cols; c++ {
+				candidates++
+				if candidates > maxShapedCandidates {
+					return results
+				}
+				block := rectangleBlock(heat, r, c, height, width)
+				if block == nil || !accept(block) {
+					continue
+				}
+				results = append(results, block)
+				if len(results) == maxShapedBlocks {
+					return results
+				}
+			}
+		}
+	}
+
+	return results
+}
+
+// rectangleSkew is how far a rectangle of the height is from a square.
+func rectangleSkew(height, groupSize int) int {
+	width := groupSize / height
+	if height > width {
+		return height - width
+	}
+	return width - height
+}
+
+// rectangleBlock returns the seats of the rectangle at (row, col), or nil when
+// one of its cells is not safe.
+func rectangleBlock(heat [][]bool, row, col, height, width int) []models.Seat {
+	block := make([]models.Seat, 0, height*width)
+	for r := row; r < row+height; r++ {
+		for c := col; c < col+width; c++ {
+			if heat[r][c] {
+				return nil
+			}
+			block = append(block, models.Seat{Row: r, Column: c})
+		}
+	}
+	return block
+}
+
+// findConnectedBlocks lists the sets of groupSize safe seats where every seat
+// is next to, in front of or behind another seat of the set. It uses
+// Redelmeier's algorithm, which grows each set from its first seat in scan
+// order and so produces every set exactly once.
+func findConnectedBlocks(cinema *models.Cinema, heat [][]bool, groupSize int, startRows []bool, accept func([]models.Seat) bool) [][]models.Seat {
+	rows := len(heat)
+	cols := len(heat[0])
+	var results [][]models.Seat
+	candidates := 0
+
+	var grow func(block []models.Seat, untried []models.Seat, seen map[models.Seat]bool, start models.Seat) bool
+	grow = func(block []models.Seat, untried []models.Seat, seen map[models.Seat]bool, start models.Seat) bool {
+		for i, seat := range untried {
+			candidates++
+			if candidates > maxShapedCandidates {
+				return false
+			}
+			block = append(block, seat)
+
+			if len(block) == groupSize {
+				found := sortedSeats(block)
+				if accept(found) {
+					results = append(results, found)
+					if len(results) == maxShapedBlocks {
+						return false
+					}
+				}
+			} else {
+				next := append([]models.Seat{}, untried[i+1:]...)
+				var added []models.Seat
+				for _, neighbor := range connectedNeighbors(cinema, seat) {
+					if seen[neighbor] || !afterInScanOrder(neighbor, start) ||
+						neighbor.Row < 0 || neighbor.Row >= rows || neighbor.Column < 0 || neighbor.Column >= cols ||
+						heat[neighbor.Row][neighbor.Column] {
+						continue
+					}
+					seen[neighbor] = true
+					added = append(added, neighbor)
+				}
+				next = append(next, added...)
+
+				more := grow(block, next, seen, start)
+				for _, neighbor := range added {
+					delete(seen, neighbor)
+				}
+				if !more {
+					return false
+				}
+			}
+
+			block = block[:len(block)-1]
+		}
+		return true
+	}
+
+	for r := 0; r < rows; r++ {
+		if startRows != nil && !startRows[r] {
+			continue
+		}
+		for c := 0; c < cols; c++ {
+			if heat[r][c] {
+				continue
+			}
+			start := models.Seat{Row: r, Column: c}
+			if !grow(nil, []models.Seat{start}, map[models.Seat]bool{start: true}, start) {
+				return results
+			}
+		}
+	}
+
+	return results
+}
+
+// connectedNeighbors lists the cells in front of, behind and next to the
+// seat. Aisles are skipped when the cinema lets blocks span them.
+func connectedNeighbors(cinema *models.Cinema, seat models.Seat) []models.Seat {
+	neighbors := []models.Seat{
+		{Row: seat.Row - 1, Column: seat.Column},
+		{Row: seat.Row + 1, Column: seat.Column},
+	}
+
+	for _, step := range []int{-1, 1} {
+		c := seat.Column + step
+		for cinema.BlocksSpanAisles && c >= 0 && c < cinema.Columns && cinema.Layout.Cell(seat.Row, c) == models.LayoutAisle {
+			c += step
+		}
+		neighbors = append(neighbors, models.Seat{Row: seat.Row, Column: c})
+	}
+
+	return neighbors
+}
+
+func afterInScanOrder(seat, start models.Seat) bool {
+	return seat.Row > start.Row || (seat.Row == start.Row && seat.Column > start.Column)
+}
+
+func sortedSeats(seats []models.Seat) []models.Seat {
+	sorted := append([]models.Seat{}, seats...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return afterInScanOrder(sorted[j], sorted[i])
+	})
+	return sorted
+}
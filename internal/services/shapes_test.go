@@ -0,0 +1,256 @@
+package services
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"cinema-reservation/internal/models"
+)
+
+func acceptAll([]models.Seat) bool { return true }
+
+func TestFindRectangleBlocks(t *testing.T) {
+	tests := []struct {
+		name      string
+		heat      [][]bool
+		groupSize int
+		want      int
+	}{
+		{"2x2 in empty 3x3", emptyHeat(3, 3), 4, 4},
+		{"2x3 and 3x2 in empty 3x3", emptyHeat(3, 3), 6, 4},
+		{"rows and columns of 3 in empty 3x3", emptyHeat(3, 3), 3, 6},
+		{"taken center", heatWith(3, 3, models.Seat{Row: 1, Column: 1}), 4, 0},
+		{"prime size in single row", emptyHeat(1, 5), 5, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocks := findRectangleBlocks(tt.heat, tt.groupSize, nil, acceptAll)
+			if len(blocks) != tt.want {
+				t.Fatalf("got %d blocks, want %d: %v", len(blocks), tt.want, blocks)
+			}
+			for _, block := range blocks {
+				if len(block) != tt.groupSize {
+					t.Errorf("block %v has %d seats, want %d", block, len(block), tt.groupSize)
+				}
+			}
+		})
+	}
+}
+
+func TestFindRectangleBlocksSquareFirst(t *testing.T) {
+	blocks := findRectangleBlocks(emptyHeat(4, 4), 4, nil, acceptAll)
+	first := blocks[0]
+	if first[0].Row == first[3].Row || first[0].Column == first[3].Column {
+		t.Errorf("first block %v is not a 2x2 square", first)
+	}
+}
+
+// TestFindConnectedBlocksMatchesBruteForce checks every connected set is
+// found exactly once against a brute force search over all seat subsets.
+func TestFindConnectedBlocksMatchesBruteForce(t *testing.T) {
+	tests := []struct {
+		name      string
+		heat      [][]bool
+		groupSize int
+	}{
+		{"pairs in 3x3", emptyHeat(3, 3), 2},
+		{"tetrominoes in 3x4", emptyHeat(3, 4), 4},
+		{"five in 3x3 around a taken seat", heatWith(3, 3, models.Seat{Row: 1, Column: 1}), 5},
+		{"six in 4x3 with taken corners", heatWith(4, 3, models.Seat{Row: 0, Column: 0}, models.Seat{Row: 3, Column: 2}), 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rows, cols := len(tt.heat), len(tt.heat[0])
+			cinema := &models.Cinema{Rows: rows, Columns: cols}
+
+			found := make(map[string]bool)
+			for _, block := range findConnectedBlocks(cinema, tt.heat, tt.groupSize, nil, acceptAll) {
+				key := fmt.Sprint(block)
+				if found[key] {
+					t.Fatalf("block %s found twice", key)
+				}
+				found[key] = true
+			}
+
+			want := bruteForceConnected(tt.heat, tt.groupSize)
+			if len(found) != len(want) {
+				t.Fatalf("found %d blocks, want %d", len(found), len(want))
+			}
+			for key := range want {
+				if !found[key] {
+					t.Errorf("block %s not found", key)
+				}
+			}
+		})
+	}
+}
+
+func TestFindConnectedBlocksSpansAisles(t *testing.T) {
+	cinema := &models.Cinema{Rows: 1, Columns: 3, Layout: models.Layout{"SAS"}, BlocksSpanAisles: true}
+	heat := buildHeatmap(cinema, nil)
+
+	blocks := findConnectedBlocks(cinema, heat, 2, nil, acceptAll)
+	if len(blocks) != 1 {
+		t.Fatalf("got %v, want the two seats across the aisle", blocks)
+	}
+
+	cinema.BlocksSpanAisles = false
+	if blocks := findConnectedBlocks(cinema, heat, 2, nil, acceptAll); len(blocks) != 0 {
+		t.Fatalf("got %v, want no block across the aisle", blocks)
+	}
+}
+
+func TestFindConnectedBlocksCap(t *testing.T) {
+	cinema := &models.Cinema{Rows: 10, Columns: 10}
+	blocks := findConnectedBlocks(cinema, emptyHeat(10, 10), 6, nil, acceptAll)
+	if len(blocks) != maxShapedBlocks {
+		t.Fatalf("got %d blocks, want the cap of %d", len(blocks), maxShapedBlocks)
+	}
+}
+
+// TestShapedSearchRejectingEveryBlock makes sure a filter that accepts
+// nothing does not make the searches walk every block of a large hall.
+func TestShapedSearchRejectingEveryBlock(t *testing.T) {
+	cinema := &models.Cinema{Rows: 50, Columns: 60}
+	heat := emptyHeat(50, 60)
+	rejectAll := func([]models.Seat) bool { return false }
+
+	for _, shape := range []models.SeatBlockShape{models.BlockShapeRectangle, models.BlockShapeAnyConnected} {
+		t.Run(string(shape), func(t *testing.T) {
+			started := time.Now()
+			blocks := findShapedBlocks(cinema, heat, maxShapedGroupSize, shape, nil, rejectAll)
+			if len(blocks) != 0 {
+				t.Fatalf("got %d blocks, want none", len(blocks))
+			}
+			if elapsed := time.Since(started); elapsed > 2*time.Second {
+				t.Errorf("search took %v", elapsed)
+			}
+		})
+	}
+}
+
+// TestFindConnectedBlocksStartRows checks that starting blocks only near a
+// required row finds every block with a seat in it.
+func TestFindConnectedBlocksStartRows(t *testing.T) {
+	const row, groupSize = 3, 3
+	cinema := &models.Cinema{Rows: 6, Columns: 4}
+	heat := emptyHeat(6, 4)
+	hasRow := func(block []models.Seat) bool { return blockHasRow(block, row) }
+
+	want := findConnectedBlocks(cinema, heat, groupSize, nil, hasRow)
+	startRows := blockStartRows(nil, cinema.Rows, groupSize, []int{row})
+	got := findConnectedBlocks(cinema, heat, groupSize, startRows, hasRow)
+	if fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Errorf("got %d blocks, want %d", len(got), len(want))
+	}
+	for r, start := range startRows {
+		if start != (r >= row-groupSize+1 && r <= row) {
+			t.Errorf("start row %d = %v", r, start)
+		}
+	}
+}
+
+// TestAccessibleSearchMatchesFilter checks that skipping the seats and rows
+// no accessible block can use finds the same blocks as filtering them.
+func TestAccessibleSearchMatchesFilter(t *testing.T) {
+	const groupSize = 3
+	showtime := &models.Showtime{
+		StartsAt: time.Now().Add(time.Hour),
+		Cinema: models.Cinema{Rows: 5, Columns: 4, Layout: models.Layout{
+			"SSSS",
+			"SSSS",
+			"SSSS",
+			"CWWC",
+			"SSSS",
+		}},
+	}
+	cinema := &showtime.Cinema
+	heat := buildHeatmap(cinema, nil)
+	now := time.Now()
+
+	for name, need := range map[string]models.AccessibilityNeed{"no need": models.AccessibilityNone, "wheelchair": models.AccessibilityWheelchair} {
+		t.Run(name, func(t *testing.T) {
+			accept := func(block []models.Seat) bool { return isAccessibleBlock(showtime, block, need, now) }
+			want := findConnectedBlocks(cinema, heat, groupSize, nil, accept)
+
+			var startRows []bool
+			if need == models.AccessibilityWheelchair {
+				startRows = blockStartRows(nil, cinema.Rows, groupSize, wheelchairRows(cinema))
+			}
+			got := findConnectedBlocks(cinema, accessibleHeat(showtime, heat, need, now), groupSize, startRows, accept)
+			if len(want) == 0 || fmt.Sprint(got) != fmt.Sprint(want) {
+				t.Errorf("got %d blocks, want %d", len(got), len(want))
+			}
+		})
+	}
+}
+
+func emptyHeat(rows, cols int) [][]bool {
+	heat := make([][]bool, rows)
+	for r := range heat {
+		heat[r] = make([]bool, cols)
+	}
+	return heat
+}
+
+func heatWith(rows, cols int, taken ...models.Seat) [][]bool {
+	heat := emptyHeat(rows, cols)
+	for _, seat := range taken {
+		heat[seat.Row][seat.Column] = true
+	}
+	return heat
+}
+
+// bruteForceConnected lists every connected set of size safe cells, keyed
+// like findConnectedBlocks output.
+func bruteForceConnected(heat [][]bool, size int) map[string]bool {
+	var cells []models.Seat
+	for r := range heat {
+		for c := range heat[r] {
+			if !heat[r][c] {
+				cells = append(cells, models.Seat{Row: r, Column: c})
+			}
+		}
+	}
+
+	results := make(map[string]bool)
+	for mask := 0; mask < 1<<len(cells); mask++ {
+		var set []models.Seat
+		for i, cell := range cells {
+			if mask&(1<<i) != 0 {
+				set = append(set, cell)
+			}
+		}
+		if len(set) == size && isConnected(set) {
+			results[fmt.Sprint(set)] = true
+		}
+	}
+	return results
+}
+
+func isConnected(set []models.Seat) bool {
+	in := make(map[models.Seat]bool, len(set))
+	for _, seat := range set {
+		in[seat] = true
+	}
+
+	seen := map[models.Seat]bool{set[0]: true}
+	stack := []models.Seat{set[0]}
+	for len(stack) > 0 {
+		seat := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		for _, next := range []models.Seat{
+			{Row: seat.Row - 1, Column: seat.Column}, {Row: seat.Row + 1, Column: seat.Column},
+			{Row: seat.Row, Column: seat.Column - 1}, {Row: seat.Row, Column: seat.Column + 1},
+		} {
+			if in[next] && !seen[next] {
+				seen[next] = true
+				stack = append(stack, next)
+			}
+		}
+	}
+	return len(seen) == len(set)
+}
@@ -0,0 +1,193 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"cinema-reservation/internal/models"
+	"cinema-reservation/internal/repositories"
+	"cinema-reservation/internal/utils"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/sirupsen/logrus"
+)
+
+type showtimeService struct {
+	showtimeRepo    repositories.ShowtimeRepository
+	cinemaRepo      repositories.CinemaRepository
+	movieRepo       repositories.MovieRepository
+	reservationRepo repositories.ReservationRepository
+	redis           *redis.Client
+}
+
+func NewShowtimeService(
+	showtimeRepo repositories.ShowtimeRepository,
+	cinemaRepo repositories.CinemaRepository,
+	movieRepo repositories.MovieRepository,
+	reservationRepo repositories.ReservationRepository,
+	redis *redis.Client,
+) ShowtimeService {
+	return &showtimeService{
+		showtimeRepo:    showtimeRepo,
+		cinemaRepo:      cinemaRepo,
+		movieRepo:       movieRepo,
+		reservationRepo: reservationRepo,
+		redis:           redis,
+	}
+}
+
+func (s *showtimeService) CreateShowtime(ctx context.Context, cinemaSlug string, req *models.ShowtimeRequest) (*models.Showtime, error) {
+	cinema, err := s.getCinema(ctx, cinemaSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	showtime := &models.Showtime{CinemaID: cinema.ID}
+	err = s.applyRequest(ctx, showtime, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.showtimeRepo.Create(ctx, showtime)
+	if err != nil {
+		logrus.WithError(err).Error("failed to create showtime")
+		return nil, utils.ErrInternalServer
+	}
+
+	return showtime, nil
+}
+
+func (s *showtimeService) ListShowtimes(ctx context.Context, cinemaSlug string) ([]models.Showtime, error) {
+	cinema, err := s.getCinema(ctx, cinemaSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	showtimes, err := s.showtimeRepo.ListByCinema(ctx, cinema.ID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to list showtimes")
+		return nil, utils.ErrInternalServer
+	}
+
+	return showtimes, nil
+}
+
+func (s *showtimeService) GetShowtime(ctx context.Context, cinemaSlug string, showtimeID uint) (*models.Showtime, error) {
+	return s.getShowtime(ctx, cinemaSlug, showtimeID)
+}
+
+func (s *showtimeService) UpdateShowtime(ctx context.Context, cinemaSlug string, showtimeID uint, req *models.ShowtimeRequest) (*models.Showtime, error) {
+	showtime, err := s.getShowtime(ctx, cinemaSlug, showtimeID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.applyRequest(ctx, showtime, req)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.showtimeRepo.Update(ctx, showtime)
+	if err != nil {
+		logrus.WithError(err).Error("failed to update showtime")
+		return nil, utils.ErrInternalServer
+	}
+
+	return showtime, nil
+}
+
+func (s *showtimeService) DeleteShowtime(ctx context.Context, cinemaSlug string, showtimeID uint) error {
+	showtime, err := s.getShowtime(ctx, cinemaSlug, showtimeID)
+	if err != nil {
+		return err
+	}
+
+	count, err := s.reservationRepo.CountReservedSeats(ctx, showtime.ID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to count reserved seats")
+		return utils.ErrInternalServer
+	}
+	if count > 0 {
+		return utils.ErrShowtimeHasReservations
+	}
+
+	err = s.showtimeRepo.Delete(ctx, showtime.ID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to delete showtime")
+		return utils.ErrInternalServer
+	}
+
+	// Nothing is reserved, but drop the seat hash so no stale key lingers
+	err = s.redis.Del(ctx, showtimeSeatsKey(showtime.ID)).Err()
+	if err != nil {
+		logrus.WithError(err).WithField("showtime_id", showtime.ID).Warn("failed to delete showtime seats from redis")
+	}
+
+	return nil
+}
+
+// applyRequest validates the movie and the schedule and copies them onto the
+// showtime.
+func (s *showtimeService) applyRequest(ctx context.Context, showtime *models.Showtime, req *models.ShowtimeRequest) error {
+	movie, err := s.movieRepo.GetByID(ctx, req.MovieID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get movie by id")
+		return utils.ErrInternalServer
+	}
+	if movie == nil {
+		return utils.ErrMovieNotFound
+	}
+
+	startsAt := req.StartsAt.UTC()
+	endsAt := startsAt.Add(time.Duration(movie.DurationMinutes) * time.Minute)
+
+	overlap, err := s.showtimeRepo.HasOverlap(ctx, showtime.CinemaID, startsAt, endsAt, showtime.ID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to check showtime overlap")
+		return utils.ErrInternalServer
+	}
+	if overlap {
+		return utils.ErrShowtimeOverlap
+	}
+
+	showtime.MovieID = movie.ID
+	showtime.Movie = movie
+	showtime.StartsAt = startsAt
+	showtime.EndsAt = endsAt
+
+	return nil
+}
+
+func (s *showtimeService) getCinema(ctx context.Context, cinemaSlug string) (*models.Cinema, error) {
+	cinema, err := s.cinemaRepo.GetBySlug(ctx, cinemaSlug)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get cinema by slug")
+		return nil, utils.ErrInternalServer
+	}
+	if cinema == nil {
+		return nil, utils.ErrCinemaNotFound
+	}
+	return cinema, nil
+}
+
+func (s *showtimeService) getShowtime(ctx context.Context, cinemaSlug string, showtimeID uint) (*models.Showtime, error) {
+	cinema, err := s.getCinema(ctx, cinemaSlug)
+	if err != nil {
+		return nil, err
+	}
+
+	return findShowtime(ctx, s.showtimeRepo, cinema.ID, showtimeID)
+}
+
+// findShowtime loads a showtime and makes sure it belongs to the given cinema.
+func findShowtime(ctx context.Context, showtimeRepo repositories.ShowtimeRepository, cinemaID, showtimeID uint) (*models.Showtime, error) {
+	showtime, err := showtimeRepo.GetByID(ctx, showtimeID)
+	if err != nil {
+		logrus.WithError(err).Error("failed to get showtime by id")
+		return nil, utils.ErrInternalServer
+	}
+	if showtime == nil || showtime.CinemaID != cinemaID {
+		return nil, utils.ErrShowtimeNotFound
+	}
+	return showtime, nil
+}
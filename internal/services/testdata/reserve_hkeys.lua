@@ -0,0 +1,122 @@
+-- KEYS[1] = Redis hash key (showtime:{showtimeID}:seats)
+-- KEYS[2] = Redis hash key of active holds (showtime:{showtimeID}:holds)
+-- KEYS[3] = Sorted set of hold expiries (holds:expiry)
+-- KEYS[4] = Hash mapping hold ID to showtime ID (holds:showtimes)
+-- KEYS[5] = Layout of the cinema (cinema:{cinemaID}:layout)
+-- ARGV[1] = minimum distance
+-- ARGV[2] = current time in milliseconds
+-- ARGV[3] = hold ID, empty to reserve the seats permanently
+-- ARGV[4] = hold expiry in milliseconds (ignored without a hold ID)
+-- ARGV[5] = showtime ID (ignored without a hold ID)
+-- ARGV[6] = layout row width, 0 when the cinema has no layout
+-- ARGV[7] = distance policy, see models.DistancePolicy
+-- ARGV[8] = number of party seats, exempt from the distance check
+-- ARGV[9..8+n] = party seats: "row:col"
+-- ARGV[9+n..] = seat list: "row:col"
+
+local min_dist = tonumber(ARGV[1])
+local now = tonumber(ARGV[2])
+local hold_id = ARGV[3]
+local expires_at = tonumber(ARGV[4])
+local showtime_id = ARGV[5]
+local layout_columns = tonumber(ARGV[6])
+local policy = ARGV[7]
+local party_count = tonumber(ARGV[8])
+local party_seats = {}
+local requested_seats = {}
+
+for i = 9, 8 + party_count do
+    party_seats[ARGV[i]] = true
+end
+
+for i = 9 + party_count, #ARGV do
+    local coord = ARGV[i]
+    table.insert(requested_seats, coord)
+end
+
+-- Same rules as models.DistancePolicy.TooClose
+local function too_close(dr, dc)
+    dr = math.abs(dr)
+    dc = math.abs(dc)
+    if policy == "chebyshev" then
+        return math.max(dr, dc) < min_dist
+    elseif policy == "euclidean" then
+        return dr * dr + dc * dc < min_dist * min_dist
+    elseif policy == "row_only" then
+        return dr == 0 and dc < min_dist
+    elseif policy == "checkerboard" then
+        return (dr == 0 or dc == 0) and dr + dc < min_dist
+    end
+    return dr + dc < min_dist
+end
+
+-- Only seats can be reserved, not aisles or gaps
+if layout_columns > 0 then
+    if redis.call("EXISTS", KEYS[5]) == 0 then
+        return {err="[LAYOUT_MISSING] Cinema layout is not loaded"}
+    end
+    for _, seat in ipairs(requested_seats) do
+        local row, col = seat:match("^(%d+):(%d+)$")
+        local offset = tonumber(row) * layout_columns + tonumber(col)
+        local cell = redis.call("GETRANGE", KEYS[5], offset, offset)
+        if cell == "" or cell == "A" or cell == "X" then
+            return {err="[NOT_A_SEAT] No seat at: " .. seat}
+        end
+    end
+end
+
+-- Release expired holds so abandoned carts do not block the seats
+local holds = redis.call("HGETALL", KEYS[2])
+for i = 1, #holds, 2 do
+    local id = holds[i]
+    local hold = cjson.decode(holds[i + 1])
+    if hold.expires_at <= now then
+        for _, seat in ipairs(hold.seats) do
+            if redis.call("HGET", KEYS[1], seat) == "hold:" .. id then
+                redis.call("HDEL", KEYS[1], seat)
+            end
+        end
+        redis.call("HDEL", KEYS[2], id)
+        redis.call("ZREM", KEYS[3], id)
+        redis.call("HDEL", KEYS[4], id)
+    end
+end
+
+-- Check if any seat is already taken
+for _, seat in ipairs(requested_seats) do
+    if redis.call("HEXISTS", KEYS[1], seat) == 1 then
+        return {err="[SEATS_RESERVED] Seat already reserved: " .. seat}
+    end
+end
+
+-- Check social distancing (held seats count as taken, the party's seats do not)
+for _, seat1 in ipairs(requested_seats) do
+    local row1, col1 = seat1:match("^(%d+):(%d+)$")
+    row1 = tonumber(row1)
+    col1 = tonumber(col1)
+
+    local keys = redis.call("HKEYS", KEYS[1])
+    for _, seat2 in ipairs(keys) do
+        local row2, col2 = seat2:match("^(%d+):(%d+)$")
+        row2 = tonumber(row2)
+        col2 = tonumber(col2)
+        if not party_seats[seat2] and too_close(row1 - row2, col1 - col2) then
+            return {err="[MIN_DISTANCE_VIOLATION]Social distancing violated near: " .. seat2}
+        end
+    end
+end
+
+-- All checks passed, reserve (or hold) the seats
+local value = "1"
+if hold_id ~= "" then
+    value = "hold:" .. hold_id
+    redis.call("HSET", KEYS[2], hold_id, cjson.encode({expires_at = expires_at, seats = requested_seats}))
+    redis.call("ZADD", KEYS[3], expires_at, hold_id)
+    redis.call("HSET", KEYS[4], hold_id, showtime_id)
+end
+
+for _, seat in ipairs(requested_seats) do
+    redis.call("HSET", KEYS[1], seat, value)
+end
+
+return "OK"
@@ -5,6 +5,7 @@ import "errors"
 var (
 	ErrCinemaNotFound       = errors.New("cinema not found")
 	ErrCinemaAlreadyExists  = errors.New("cinema with this name already exists")
+	ErrInvalidLayout        = errors.New("invalid cinema layout")
 	ErrInvalidInput         = errors.New("invalid input provided")
 	ErrSeatsAlreadyReserved = errors.New("one or more seats are already reserved")
 	ErrSeatsNotAvailable    = errors.New("selected seats are not available")
@@ -14,4 +15,57 @@ var (
 	ErrDatabaseConnection   = errors.New("database connection failed")
 	ErrRateLimitExceeded    = errors.New("rate limit exceeded")
 	ErrSeatsNotReserved     = errors.New("one or more seats are not currently reserved")
+
+	ErrMovieNotFound           = errors.New("movie not found")
+	ErrMovieAlreadyExists      = errors.New("movie with this title already exists")
+	ErrShowtimeNotFound        = errors.New("showtime not found")
+	ErrInvalidShowtimeID       = errors.New("invalid showtime id")
+	ErrShowtimeOverlap         = errors.New("showtime overlaps another showtime in this cinema")
+	ErrShowtimeHasReservations = errors.New("showtime has active reservations")
+	ErrShowtimeAlreadyStarted  = errors.New("showtime has already started")
+
+	ErrHoldNotFound = errors.New("hold not found or expired")
+
+	ErrReservationNotFound   = errors.New("reservation not found")
+	ErrInvalidReservationID  = errors.New("invalid reservation id")
+	ErrSeatsNotInReservation = errors.New("one or more seats do not belong to the reservation")
+	ErrPartyNotFound         = errors.New("party reservation not found")
+	ErrPartyShowtimeMismatch = errors.New("party reservation is for another showtime")
+
+	ErrSeatReleaseNotFound  = errors.New("seat release not found")
+	ErrInvalidSeatReleaseID = errors.New("invalid seat release id")
+
+	ErrDuplicateSeatCategory = errors.New("seat category priced more than once")
+
+	ErrInvalidBlockShape     = errors.New("invalid seat block shape")
+	ErrInvalidRow            = errors.New("invalid row")
+	ErrGroupTooLargeForShape = errors.New("group too large for the seat block shape")
+	ErrInvalidBlockFormat    = errors.New("invalid seat block format")
+	ErrRunsNeedRowShape      = errors.New("runs format only supports the row shape")
+	ErrInvalidCursor         = errors.New("invalid cursor")
+
+	ErrInvalidAccessibility       = errors.New("invalid accessibility need")
+	ErrCompanionWithoutWheelchair = errors.New("companion seat booked without an adjacent wheelchair space")
+	ErrWheelchairSpaceNotReleased = errors.New("wheelchair space is not on general sale yet")
+
+	ErrInvalidIdempotencyKey    = errors.New("invalid idempotency key")
+	ErrIdempotencyKeyInProgress = errors.New("request with this idempotency key is in progress")
+	ErrIdempotencyKeyReused     = errors.New("idempotency key reused with another request")
+
+	ErrEmailAlreadyRegistered = errors.New("email is already registered")
+	ErrInvalidCredentials     = errors.New("invalid email or password")
+	ErrUnauthorized           = errors.New("authentication required")
+	ErrInvalidToken           = errors.New("invalid or expired token")
+	ErrNotReservationOwner    = errors.New("reservation belongs to another user")
+	ErrInsufficientRole       = errors.New("role does not allow this action")
+	ErrCinemaNotManaged       = errors.New("cinema is not managed by the user")
+	ErrUserNotFound           = errors.New("user not found")
+	ErrInvalidUserID          = errors.New("invalid user id")
+
+	ErrInvalidAPIKey           = errors.New("invalid or revoked api key")
+	ErrAPIKeyNotFound          = errors.New("api key not found")
+	ErrInvalidAPIKeyID         = errors.New("invalid api key id")
+	ErrInvalidUsageRange       = errors.New("invalid usage range")
+	ErrCinemaNotInKeyScope     = errors.New("cinema is not in the scope of the api key")
+	ErrAPIKeyRateLimitExceeded = errors.New("api key rate limit exceeded")
 )
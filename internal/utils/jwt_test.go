@@ -0,0 +1,51 @@
+package utils
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseToken(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Unix(1_700_000_000, 0)
+	claims := TokenClaims{Subject: "42", Role: "customer", IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Hour).Unix()}
+
+	token, err := SignToken(secret, claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := ParseToken(secret, token, now)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if *got != claims {
+		t.Errorf("claims = %+v, want %+v", *got, claims)
+	}
+
+	parts := strings.Split(token, ".")
+	forged, _ := SignToken(secret, TokenClaims{Subject: "1", Role: "staff", ExpiresAt: claims.ExpiresAt})
+	forgedParts := strings.Split(forged, ".")
+
+	tests := []struct {
+		name   string
+		secret string
+		token  string
+		now    time.Time
+	}{
+		{"expired", "test-secret", token, now.Add(time.Hour)},
+		{"other secret", "other-secret", token, now},
+		{"swapped payload", "test-secret", parts[0] + "." + forgedParts[1] + "." + parts[2], now},
+		{"unsigned", "test-secret", "eyJhbGciOiJub25lIn0" + "." + parts[1] + ".", now},
+		{"malformed", "test-secret", "not-a-token", now},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseToken([]byte(tt.secret), tt.token, tt.now)
+			if err != ErrInvalidToken {
+				t.Errorf("got %v, want ErrInvalidToken", err)
+			}
+		})
+	}
+}
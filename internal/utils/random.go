@@ -0,0 +1,44 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"strings"
+)
+
+// bookingCodeAlphabet leaves out characters that are easily confused when a
+// code is read out loud or typed (0/O, 1/I/L).
+const bookingCodeAlphabet = "ABCDEFGHJKMNPQRSTUVWXYZ23456789"
+
+const BookingCodeLength = 8
+
+// RandomToken returns a hex encoded string of n random bytes.
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// GenerateBookingCode returns a random, human friendly reservation code.
+func GenerateBookingCode() (string, error) {
+	max := big.NewInt(int64(len(bookingCodeAlphabet)))
+	code := make([]byte, BookingCodeLength)
+	for i := range code {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", err
+		}
+		code[i] = bookingCodeAlphabet[n.Int64()]
+	}
+	return string(code), nil
+}
+
+// NormalizeBookingCode uppercases a code typed by a person and drops the
+// spaces and dashes used to group it.
+func NormalizeBookingCode(code string) string {
+	code = strings.ToUpper(code)
+	return strings.NewReplacer(" ", "", "-", "").Replace(code)
+}
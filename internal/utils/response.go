@@ -13,6 +13,9 @@ type Response struct {
 	Message string      `json:"message"`
 	Data    interface{} `json:"data,omitempty"`
 	Code    string      `json:"code,omitempty"`
+	// NextCursor fetches the next page of a paginated list, empty on the
+	// last page
+	NextCursor string `json:"next_cursor,omitempty"`
 }
 
 type ErrorMapping struct {
@@ -25,6 +28,27 @@ var errorMappings = map[error]ErrorMapping{
 	// Cinema errors
 	ErrCinemaNotFound:      {http.StatusNotFound, "Cinema not found", "CINEMA_NOT_FOUND"},
 	ErrCinemaAlreadyExists: {http.StatusConflict, "Cinema with this name already exists", "CINEMA_EXISTS"},
+	ErrInvalidLayout: {
+		StatusCode: http.StatusBadRequest,
+		Message:    "Layout rows must have the same length, use only S, P, V, L, W, C, A and X and contain a seat",
+		Code:       "INVALID_LAYOUT",
+	},
+	ErrDuplicateSeatCategory: {http.StatusBadRequest, "Seat category priced more than once", "DUPLICATE_SEAT_CATEGORY"},
+
+	// Movie errors
+	ErrMovieNotFound:      {http.StatusNotFound, "Movie not found", "MOVIE_NOT_FOUND"},
+	ErrMovieAlreadyExists: {http.StatusConflict, "Movie with this title already exists", "MOVIE_EXISTS"},
+
+	// Showtime errors
+	ErrShowtimeNotFound:  {http.StatusNotFound, "Showtime not found", "SHOWTIME_NOT_FOUND"},
+	ErrInvalidShowtimeID: {http.StatusBadRequest, "Invalid showtime id", "INVALID_SHOWTIME_ID"},
+	ErrShowtimeOverlap:   {http.StatusConflict, "Showtime overlaps another showtime in this cinema", "SHOWTIME_OVERLAP"},
+	ErrShowtimeHasReservations: {
+		StatusCode: http.StatusConflict,
+		Message:    "Showtime has active reservations",
+		Code:       "SHOWTIME_HAS_RESERVATIONS",
+	},
+	ErrShowtimeAlreadyStarted: {http.StatusConflict, "Showtime has already started", "SHOWTIME_STARTED"},
 
 	// Reservation errors
 	ErrSeatsAlreadyReserved: {http.StatusConflict, "One or more seats are already reserved", "SEATS_RESERVED"},
@@ -36,6 +60,88 @@ var errorMappings = map[error]ErrorMapping{
 		Message:    "All specified seats must be currently reserved to cancel",
 		Code:       "SEATS_NOT_RESERVED",
 	},
+	ErrInvalidBlockShape: {http.StatusBadRequest, "Shape must be row, rectangle or any-connected", "INVALID_BLOCK_SHAPE"},
+	ErrInvalidRow:        {http.StatusBadRequest, "Row must be a row of the cinema", "INVALID_ROW"},
+	ErrGroupTooLargeForShape: {
+		StatusCode: http.StatusBadRequest,
+		Message:    "Groups in a rectangle or any-connected shape can have at most 8 seats",
+		Code:       "GROUP_TOO_LARGE_FOR_SHAPE",
+	},
+	ErrInvalidBlockFormat:   {http.StatusBadRequest, "Format must be seats or runs", "INVALID_BLOCK_FORMAT"},
+	ErrRunsNeedRowShape:     {http.StatusBadRequest, "The runs format only supports the row shape", "RUNS_NEED_ROW_SHAPE"},
+	ErrInvalidCursor:        {http.StatusBadRequest, "Invalid cursor", "INVALID_CURSOR"},
+	ErrInvalidAccessibility: {http.StatusBadRequest, "Invalid accessibility need", "INVALID_ACCESSIBILITY"},
+	ErrCompanionWithoutWheelchair: {
+		StatusCode: http.StatusBadRequest,
+		Message:    "Companion seats can only be booked together with an adjacent wheelchair space",
+		Code:       "COMPANION_WITHOUT_WHEELCHAIR",
+	},
+	ErrWheelchairSpaceNotReleased: {
+		StatusCode: http.StatusConflict,
+		Message:    "Wheelchair spaces are not on general sale yet",
+		Code:       "WHEELCHAIR_SPACE_NOT_RELEASED",
+	},
+	ErrReservationNotFound:  {http.StatusNotFound, "Reservation not found", "RESERVATION_NOT_FOUND"},
+	ErrInvalidReservationID: {http.StatusBadRequest, "Invalid reservation id", "INVALID_RESERVATION_ID"},
+	ErrSeatsNotInReservation: {
+		StatusCode: http.StatusBadRequest,
+		Message:    "One or more seats do not belong to the reservation",
+		Code:       "SEATS_NOT_IN_RESERVATION",
+	},
+
+	ErrPartyNotFound: {http.StatusNotFound, "Party reservation not found", "PARTY_NOT_FOUND"},
+	ErrPartyShowtimeMismatch: {
+		StatusCode: http.StatusBadRequest,
+		Message:    "Party reservation is for another showtime",
+		Code:       "PARTY_SHOWTIME_MISMATCH",
+	},
+
+	// Hold errors
+	ErrHoldNotFound: {http.StatusNotFound, "Hold not found or expired", "HOLD_NOT_FOUND"},
+
+	// Admin errors
+	ErrSeatReleaseNotFound:  {http.StatusNotFound, "Seat release not found", "SEAT_RELEASE_NOT_FOUND"},
+	ErrInvalidSeatReleaseID: {http.StatusBadRequest, "Invalid seat release id", "INVALID_SEAT_RELEASE_ID"},
+
+	// Idempotency errors
+	ErrInvalidIdempotencyKey: {http.StatusBadRequest, "Idempotency key must be at most 255 characters", "INVALID_IDEMPOTENCY_KEY"},
+	ErrIdempotencyKeyInProgress: {
+		StatusCode: http.StatusConflict,
+		Message:    "A request with this idempotency key is still in progress",
+		Code:       "IDEMPOTENCY_IN_PROGRESS",
+	},
+	ErrIdempotencyKeyReused: {
+		StatusCode: http.StatusUnprocessableEntity,
+		Message:    "Idempotency key was already used with a different request body",
+		Code:       "IDEMPOTENCY_KEY_REUSED",
+	},
+
+	// Auth errors
+	ErrEmailAlreadyRegistered: {http.StatusConflict, "Email is already registered", "EMAIL_ALREADY_REGISTERED"},
+	ErrInvalidCredentials:     {http.StatusUnauthorized, "Invalid email or password", "INVALID_CREDENTIALS"},
+	ErrUnauthorized:           {http.StatusUnauthorized, "Authentication required", "UNAUTHORIZED"},
+	ErrInvalidToken:           {http.StatusUnauthorized, "Invalid or expired token", "INVALID_TOKEN"},
+	ErrNotReservationOwner: {
+		StatusCode: http.StatusForbidden,
+		Message:    "One or more seats belong to another user's reservation",
+		Code:       "NOT_RESERVATION_OWNER",
+	},
+	ErrInsufficientRole: {http.StatusForbidden, "Your role does not allow this action", "INSUFFICIENT_ROLE"},
+	ErrCinemaNotManaged: {http.StatusForbidden, "You do not manage this cinema", "CINEMA_NOT_MANAGED"},
+	ErrUserNotFound:     {http.StatusNotFound, "User not found", "USER_NOT_FOUND"},
+	ErrInvalidUserID:    {http.StatusBadRequest, "Invalid user id", "INVALID_USER_ID"},
+
+	// API key errors
+	ErrInvalidAPIKey:     {http.StatusUnauthorized, "Invalid or revoked API key", "INVALID_API_KEY"},
+	ErrAPIKeyNotFound:    {http.StatusNotFound, "API key not found", "API_KEY_NOT_FOUND"},
+	ErrInvalidAPIKeyID:   {http.StatusBadRequest, "Invalid API key id", "INVALID_API_KEY_ID"},
+	ErrInvalidUsageRange: {http.StatusBadRequest, "Usage range must be from <= to, at most 366 days, as YYYY-MM-DD", "INVALID_USAGE_RANGE"},
+	ErrCinemaNotInKeyScope: {
+		StatusCode: http.StatusForbidden,
+		Message:    "The API key may not book seats in this cinema",
+		Code:       "CINEMA_NOT_IN_API_KEY_SCOPE",
+	},
+	ErrAPIKeyRateLimitExceeded: {http.StatusTooManyRequests, "API key rate limit exceeded", "API_KEY_RATE_LIMIT_EXCEEDED"},
 
 	// General errors
 	ErrInvalidInput:       {http.StatusBadRequest, "Invalid input provided", "INVALID_INPUT"},
@@ -52,6 +158,16 @@ func SuccessResponse(c *gin.Context, statusCode int, message string, data interf
 	})
 }
 
+// PageResponse is SuccessResponse for one page of a paginated list.
+func PageResponse(c *gin.Context, statusCode int, message string, data interface{}, nextCursor string) {
+	c.JSON(statusCode, Response{
+		Success:    true,
+		Message:    message,
+		Data:       data,
+		NextCursor: nextCursor,
+	})
+}
+
 func ErrorResponse(c *gin.Context, err error) {
 	response := Response{
 		Success: false,
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"os"
 	"sync"
 	"testing"
 )
@@ -15,7 +16,7 @@ func TestLoopingSeatRequestPattern(t *testing.T) {
 		rows          = 10
 		columns       = 15
 		targetURL     = "http://localhost:8080/api/v1/reservations"
-		cinemaSlug    = "grand-cinema-downtown"
+		showtimeID    = 1
 	)
 
 	type SeatRequest struct {
@@ -24,10 +25,13 @@ func TestLoopingSeatRequestPattern(t *testing.T) {
 	}
 
 	type ReservationRequest struct {
-		CinemaSlug string        `json:"cinema_slug"`
+		ShowtimeID uint          `json:"showtime_id"`
 		Seats      []SeatRequest `json:"seats"`
 	}
 
+	// Token of a registered user, see the Testing section of the README
+	authToken := os.Getenv("AUTH_TOKEN")
+
 	client := &http.Client{}
 	var wg sync.WaitGroup
 	var mu sync.Mutex
@@ -65,7 +69,7 @@ func TestLoopingSeatRequestPattern(t *testing.T) {
 
 				// --- Send request
 				req := ReservationRequest{
-					CinemaSlug: cinemaSlug,
+					ShowtimeID: showtimeID,
 					Seats:      []SeatRequest{{Row: row, Column: col}},
 				}
 				wg.Add(1)
@@ -73,7 +77,10 @@ func TestLoopingSeatRequestPattern(t *testing.T) {
 					defer wg.Done()
 					body, _ := json.Marshal(r)
 					<-fire
-					resp, err := client.Post(targetURL, "application/json", bytes.NewBuffer(body))
+					httpReq, _ := http.NewRequest(http.MethodPost, targetURL, bytes.NewBuffer(body))
+					httpReq.Header.Set("Content-Type", "application/json")
+					httpReq.Header.Set("Authorization", "Bearer "+authToken)
+					resp, err := client.Do(httpReq)
 					if err != nil {
 
 						mu.Lock()
@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"fmt"
 	"net/http"
+	"os"
 	"sync"
 	"testing"
 )
@@ -12,14 +13,14 @@ func TestConcurrentSeatReservation(t *testing.T) {
 	const (
 		totalRequests = 10000
 		targetURL     = "http://localhost:8080/api/v1/reservations"
-		cinemaSlug    = "grand-cinema-downtown"
+		showtimeID    = 1
 		row           = 0
 		column        = 0
 	)
 
 	payload := []byte(fmt.Sprintf(`
 		{
-			"cinema_slug": "%s",
+			"showtime_id": %d,
 			"seats": [
 					{
 							"row": %d,
@@ -27,13 +28,16 @@ func TestConcurrentSeatReservation(t *testing.T) {
 					}
 			]
 		}
-	`, cinemaSlug, row, column))
+	`, showtimeID, row, column))
 	var (
 		wg     sync.WaitGroup
 		mu     sync.Mutex
 		counts = make(map[int]int) // key: HTTP status code, value: count
 	)
 
+	// Token of a registered user, see the Testing section of the README
+	authToken := os.Getenv("AUTH_TOKEN")
+
 	client := &http.Client{}
 	fire := make(chan struct{})
 	for i := 0; i < totalRequests; i++ {
@@ -41,7 +45,10 @@ func TestConcurrentSeatReservation(t *testing.T) {
 		go func() {
 			defer wg.Done()
 			<-fire
-			resp, err := client.Post(targetURL, "application/json", bytes.NewBuffer(payload))
+			req, _ := http.NewRequest(http.MethodPost, targetURL, bytes.NewBuffer(payload))
+			req.Header.Set("Content-Type", "application/json")
+			req.Header.Set("Authorization", "Bearer "+authToken)
+			resp, err := client.Do(req)
 			if err != nil {
 				mu.Lock()
 				counts[-1]++